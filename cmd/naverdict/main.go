@@ -0,0 +1,186 @@
+// Command naverdict is a command line tool for the NaverDictionary scraper.
+//
+// Usage:
+//
+//	naverdict import <dump.xml|dump.json>... <index.json>
+//	naverdict schema <dir>
+//	naverdict export [-tsv] [-columns term,glosses] [-header=false] [-bom=false] <word>...
+//	naverdict sheet [-pdf] [-quiz] [-title <title>] [-locale ko] <word>...
+//
+// import builds an offline index from 한국어기초사전 (or other LMF) exports,
+// to be served by setting NAVERDICT_OFFLINE_INDEX.
+//
+// schema writes the JSON Schemas of the REST API responses into dir, e.g.
+// schemas/.
+//
+// export looks up words and writes them to stdout as CSV, or as TSV to
+// paste into Quizlet.
+//
+// sheet looks up words and writes a printable vocabulary sheet to stdout,
+// as HTML or PDF.
+//
+// export and sheet look words up offline like the REST server, if
+// NAVERDICT_OFFLINE_INDEX and NAVERDICT_OFFLINE_MODE are set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"naverdictionary/scraper"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: naverdict import <dump.xml|dump.json>... <index.json>")
+	fmt.Fprintln(os.Stderr, "       naverdict schema <dir>")
+	fmt.Fprintln(os.Stderr, "       naverdict export [-tsv] [-columns term,glosses] [-header=false] [-bom=false] <word>...")
+	fmt.Fprintln(os.Stderr, "       naverdict sheet [-pdf] [-quiz] [-title <title>] [-locale ko] <word>...")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = importdumps(os.Args[2:])
+	case "schema":
+		err = writeschemas(os.Args[2:])
+	case "export":
+		err = exportwords(os.Args[2:])
+	case "sheet":
+		err = writesheet(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "naverdict:", err)
+		os.Exit(1)
+	}
+}
+
+// Import dictionary dumps into an offline index.
+func importdumps(args []string) error {
+	if len(args) < 2 {
+		usage()
+	}
+	dumps, indexpath := args[:len(args)-1], args[len(args)-1]
+	index := scraper.NewOfflineIndex()
+	for _, dump := range dumps {
+		count, errimport := scraper.ImportKrdictFile(dump, index)
+		if errimport != nil {
+			return errimport
+		}
+		fmt.Printf("imported %d entries from %s\n", count, dump)
+	}
+	return index.Save(indexpath)
+}
+
+// Write the JSON Schemas of the wire format into a directory.
+func writeschemas(args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+	names := make([]string, 0, len(scraper.WireSchemas))
+	for name := range scraper.WireSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errmkdir := os.MkdirAll(args[0], 0o755)
+	if errmkdir != nil {
+		return errmkdir
+	}
+	for _, name := range names {
+		schema, errschema := scraper.JSONSchema(scraper.WireSchemas[name])
+		if errschema != nil {
+			return errschema
+		}
+		path := filepath.Join(args[0], name)
+		errwrite := os.WriteFile(path, schema, 0o644)
+		if errwrite != nil {
+			return errwrite
+		}
+		fmt.Printf("wrote %s\n", path)
+	}
+	return nil
+}
+
+// Look up words and write them as a vocabulary list to stdout.
+func exportwords(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	tsv := flags.Bool("tsv", false, "write tab-separated values, as pasted into Quizlet")
+	columns := flags.String("columns", "", "comma-separated columns, some of "+strings.Join(scraper.VocabularyColumnNames(), ","))
+	header := flags.Bool("header", true, "write a header row, for CSV only")
+	bom := flags.Bool("bom", true, "start with a UTF-8 byte order mark for Excel, for CSV only")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		usage()
+	}
+
+	format := scraper.CSV
+	format.Header = *header
+	format.ByteOrderMark = *bom
+	if *tsv {
+		format = scraper.TSV
+	}
+	if *columns != "" {
+		parsed, errparse := scraper.ParseVocabularyColumns(*columns)
+		if errparse != nil {
+			return errparse
+		}
+		format.Columns = parsed
+	}
+
+	entries, errlookup := lookupwords(flags.Args())
+	if errlookup != nil {
+		return errlookup
+	}
+	return format.Write(os.Stdout, entries)
+}
+
+// Look up words and write them as a printable vocabulary sheet to stdout.
+func writesheet(args []string) error {
+	flags := flag.NewFlagSet("sheet", flag.ExitOnError)
+	pdf := flags.Bool("pdf", false, "write a PDF document instead of HTML")
+	quiz := flags.Bool("quiz", false, "leave the meanings blank")
+	title := flags.String("title", "", "heading of every page")
+	locale := flags.String("locale", scraper.DefaultLocale, "locale of the column headings, one of "+strings.Join(scraper.Locales(), ", "))
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		usage()
+	}
+
+	entries, errlookup := lookupwords(flags.Args())
+	if errlookup != nil {
+		return errlookup
+	}
+	sheet := scraper.VocabularySheet{Title: *title, Entries: entries, Quiz: *quiz, Locale: scraper.MatchLocale(*locale)}
+	if *pdf {
+		return sheet.WritePDF(os.Stdout)
+	}
+	return sheet.WriteHTML(os.Stdout)
+}
+
+// Look up words in the batch lane, offline if the environment configures
+// an offline index as for the REST server.
+func lookupwords(words []string) ([]scraper.Entry, error) {
+	erroffline := scraper.UseOfflineIndexFile(os.Getenv("NAVERDICT_OFFLINE_INDEX"), os.Getenv("NAVERDICT_OFFLINE_MODE"))
+	if erroffline != nil {
+		return nil, erroffline
+	}
+	provider := scraper.InLane(scraper.DefaultProvider, scraper.Batch)
+	entries := []scraper.Entry{}
+	for _, word := range words {
+		entry, errentry := scraper.GetEntryFrom(provider, word)
+		if errentry != nil {
+			return nil, fmt.Errorf("%s: %v", word, errentry)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -9,4 +9,5 @@ replace naverdictionary/scraper => ./scraper
 require (
 	github.com/aws/aws-lambda-go v1.47.0
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	golang.org/x/image v0.25.0
 )
@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"naverdictionary/scraper"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Proxies Naver requests are sent through, if any.
+var proxypool *scraper.ProxyPool
+
+// Bearer token of the debug routes, which are not served without one.
+var debugtoken string
+
+// Configure the scraper from environment variables.
+//
+//	NAVERDICT_OFFLINE_INDEX    Path of an offline index built by `naverdict import`.
+//	NAVERDICT_OFFLINE_MODE     primary or fallback. Empty only adds the `offline` dictionary.
+//	NAVERDICT_ALERT_TOKEN      Telegram bot token used to send schema drift alerts.
+//	NAVERDICT_ALERT_CHAT       Telegram chat id that receives schema drift alerts.
+//	NAVERDICT_ALERT_RATE       Drift rate between 0 and 1 that triggers an alert. Defaults to 0.5.
+//	NAVERDICT_HTTP_MODE        record or replay, to store Naver responses as fixtures or serve them offline.
+//	NAVERDICT_HTTP_FIXTURES    Directory of the fixtures.
+//	NAVERDICT_RATE             Requests per second sent to each Naver host. Defaults to 10, and 0 disables the limit.
+//	NAVERDICT_BURST            Requests that can be sent to a Naver host at once. Defaults to 20.
+//	NAVERDICT_PROXIES          Comma-separated http://, https:// or socks5:// proxies to send Naver requests through.
+//	NAVERDICT_USER_AGENT       User-Agent sent to Naver.
+//	NAVERDICT_ACCEPT_LANGUAGE  Accept-Language sent to Naver.
+//	NAVERDICT_TEMPLATES        Comma-separated name[:format]=path message templates, selectable with format=name.
+//	NAVERDICT_DETAIL           compact, standard or full. Detail of built-in formats without detail=. Defaults to standard.
+//	NAVERDICT_CARD_FONT        Path of a TrueType or OpenType font tried first on word cards, e.g. one with hanja.
+//	NAVERDICT_DEBUG_TOKEN      Bearer token required by the /debug routes, which are not served without one.
+func configure() error {
+	debugtoken = os.Getenv("NAVERDICT_DEBUG_TOKEN")
+
+	errtransport := configuretransport()
+	if errtransport != nil {
+		return errtransport
+	}
+
+	errratelimit := configureratelimit()
+	if errratelimit != nil {
+		return errratelimit
+	}
+
+	// Record/replay wraps the transport configured above.
+	errreplay := scraper.UseRecordReplay(os.Getenv("NAVERDICT_HTTP_MODE"), os.Getenv("NAVERDICT_HTTP_FIXTURES"))
+	if errreplay != nil {
+		return errreplay
+	}
+
+	errschema := configureschema()
+	if errschema != nil {
+		return errschema
+	}
+
+	errtemplates := configuretemplates()
+	if errtemplates != nil {
+		return errtemplates
+	}
+
+	detail, errdetail := scraper.ParseDetail(os.Getenv("NAVERDICT_DETAIL"))
+	if errdetail != nil {
+		return errdetail
+	}
+	scraper.DefaultDetail = detail
+
+	if cardfont := os.Getenv("NAVERDICT_CARD_FONT"); cardfont != "" {
+		errcardfont := scraper.LoadCardFont(cardfont)
+		if errcardfont != nil {
+			return errcardfont
+		}
+	}
+
+	return scraper.UseOfflineIndexFile(os.Getenv("NAVERDICT_OFFLINE_INDEX"), os.Getenv("NAVERDICT_OFFLINE_MODE"))
+}
+
+// Configure schema drift alerts.
+func configureschema() error {
+	token, chat := os.Getenv("NAVERDICT_ALERT_TOKEN"), os.Getenv("NAVERDICT_ALERT_CHAT")
+	if token == "" || chat == "" {
+		return nil
+	}
+	threshold := 0.5
+	if rate := os.Getenv("NAVERDICT_ALERT_RATE"); rate != "" {
+		parsed, errparse := strconv.ParseFloat(rate, 64)
+		if errparse != nil || parsed <= 0 || parsed > 1 {
+			msg := fmt.Sprintf("invalid NAVERDICT_ALERT_RATE %q, want a number between 0 and 1", rate)
+			return errors.New(msg)
+		}
+		threshold = parsed
+	}
+	scraper.DefaultSchemaMonitor.Threshold = threshold
+	scraper.DefaultSchemaMonitor.Alerter = &scraper.TelegramAlerter{Token: token, ChatId: chat}
+	return nil
+}
+
+// Configure the rate limit of requests to Naver.
+func configureratelimit() error {
+	if rate := os.Getenv("NAVERDICT_RATE"); rate != "" {
+		parsed, errparse := strconv.ParseFloat(rate, 64)
+		if errparse != nil || parsed < 0 {
+			msg := fmt.Sprintf("invalid NAVERDICT_RATE %q, want a number of requests per second", rate)
+			return errors.New(msg)
+		}
+		scraper.DefaultRateLimiter.Rate = parsed
+	}
+	if burst := os.Getenv("NAVERDICT_BURST"); burst != "" {
+		parsed, errparse := strconv.Atoi(burst)
+		if errparse != nil || parsed < 1 {
+			msg := fmt.Sprintf("invalid NAVERDICT_BURST %q, want a positive number of requests", burst)
+			return errors.New(msg)
+		}
+		scraper.DefaultRateLimiter.Burst = parsed
+	}
+	return nil
+}
+
+// Configure the proxies and headers of requests to Naver.
+func configuretransport() error {
+	options := []scraper.ClientOption{}
+	if proxies := os.Getenv("NAVERDICT_PROXIES"); proxies != "" {
+		pool, errpool := scraper.NewProxyPool(strings.Split(proxies, ","))
+		if errpool != nil {
+			return errpool
+		}
+		pool.StartHealthChecks(5 * time.Minute)
+		proxypool = pool
+		options = append(options, scraper.WithProxyPool(pool))
+	}
+	if useragent := os.Getenv("NAVERDICT_USER_AGENT"); useragent != "" {
+		options = append(options, scraper.WithUserAgent(useragent))
+	}
+	if language := os.Getenv("NAVERDICT_ACCEPT_LANGUAGE"); language != "" {
+		options = append(options, scraper.WithAcceptLanguage(language))
+	}
+	return scraper.DefaultClient.Configure(options...)
+}
+
+// Configure message templates, e.g. card=card.tmpl,tgcard:markdownv2=card.md.tmpl
+func configuretemplates() error {
+	templates := os.Getenv("NAVERDICT_TEMPLATES")
+	if templates == "" {
+		return nil
+	}
+	for _, template := range strings.Split(templates, ",") {
+		name, path, found := strings.Cut(template, "=")
+		if !found || name == "" || path == "" {
+			msg := fmt.Sprintf("invalid NAVERDICT_TEMPLATES entry %q, want name[:format]=path", template)
+			return errors.New(msg)
+		}
+		var format *scraper.Format
+		name, formatname, hasformat := strings.Cut(name, ":")
+		if hasformat && formatname != "plain" {
+			renderer, errrenderer := scraper.GetRenderer(formatname)
+			if errrenderer != nil {
+				return errrenderer
+			}
+			rendererformat, isformat := renderer.(*scraper.Format)
+			if !isformat {
+				msg := fmt.Sprintf("format %q of template %q cannot style templates", formatname, name)
+				return errors.New(msg)
+			}
+			format = rendererformat
+		}
+		renderer, errtemplate := scraper.LoadTemplateRenderer(name, path, format)
+		if errtemplate != nil {
+			return errtemplate
+		}
+		scraper.RegisterRenderer(renderer)
+	}
+	return nil
+}
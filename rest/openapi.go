@@ -0,0 +1,252 @@
+package rest
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"naverdictionary/scraper"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiparameter is a query parameter of an operation.
+type apiparameter struct {
+	name        string
+	description string
+	kind        string   // JSON Schema type, string if empty.
+	values      []string // Allowed values, any if empty.
+	required    bool
+}
+
+// apioperation documents a route in the OpenAPI document.
+type apioperation struct {
+	method      string
+	path        string
+	tag         string
+	summary     string
+	description string
+	parameters  []apiparameter
+	body        interface{} // JSON request body, nil if none.
+	response    interface{} // JSON response body, nil if the response is contenttype.
+	contenttype string
+	authorised  bool // Whether the NAVERDICT_DEBUG_TOKEN bearer token is required.
+}
+
+// Documented routes, in the order of SetupRouter. Built on each request, to
+// list the providers and renderers registered by then.
+func apioperations() []apioperation {
+	word := apiparameter{name: "word", description: "Korean word to look up, e.g. 사랑.", required: true}
+	dict := apiparameter{name: "dict", description: "Dictionary to look the word up in.", values: scraper.ProviderNames()}
+	locale := apiparameter{name: "locale", description: "Language of labels and replies. Follows the Accept-Language header if empty.", values: scraper.Locales()}
+	detail := apiparameter{name: "detail", description: "How much of the entry to show.", values: []string{"compact", "standard", "full"}}
+	lookup := []apiparameter{word, dict, locale}
+	columns := apiparameter{name: "columns", description: "Comma-separated columns, some of " + strings.Join(scraper.VocabularyColumnNames(), ", ") + "."}
+	header := apiparameter{name: "header", description: "Whether to write a header row.", kind: "boolean"}
+	bom := apiparameter{name: "bom", description: "Whether to start with a UTF-8 byte order mark, for Excel. Defaults to true for CSV.", kind: "boolean"}
+	quiz := apiparameter{name: "quiz", description: "Whether to leave the meanings blank.", kind: "boolean"}
+
+	operations := []apioperation{
+		{method: "GET", path: "/", tag: "General", summary: "Welcome Page", parameters: []apiparameter{locale}, response: WelcomeResponse{}},
+		{method: "GET", path: "/get", tag: "Lookup", summary: "Get Dictionary Info",
+			description: "Looks up a word and summarises its entry. A word outside the TOPIK levels or below the importance is answered with a 404 error.",
+			parameters: append(lookup,
+				apiparameter{name: "topik", description: "Comma-separated TOPIK levels to accept, e.g. 1,2 or elementary."},
+				apiparameter{name: "min_importance", description: "Least number of stars to accept, from 0 to 3.", kind: "integer"},
+			),
+			response: scraper.LookupResponse{}},
+		{method: "GET", path: "/get/entry", tag: "Lookup", summary: "Get Structured Entry", parameters: lookup, response: scraper.EntryResponse{}},
+		{method: "GET", path: "/get/entryinfo", tag: "Lookup", summary: "Get Entry Info Raw", description: "Naver's entry response, as it is.", parameters: lookup, response: RawResponse{}},
+		{method: "GET", path: "/get/searchinfo", tag: "Lookup", summary: "Get Search Info Raw", description: "Naver's search response, as it is.", parameters: lookup, response: RawResponse{}},
+		{method: "GET", path: "/get/message", tag: "Lookup", summary: "Get Message",
+			description: "Renders the entry as a chat message. With max_length, the message is also split into pages of at most that many characters.",
+			parameters: append(lookup,
+				apiparameter{name: "format", description: "Format of the message.", values: scraper.RendererNames()},
+				detail,
+				apiparameter{name: "max_length", description: "Longest page, in characters.", kind: "integer"},
+			),
+			response: MessageResponse{}},
+		{method: "GET", path: "/get/card.png", tag: "Lookup", summary: "Get Word Card", description: "Draws the entry as an 800 pixel wide PNG image.", parameters: lookup, contenttype: "image/png"},
+		{method: "GET", path: "/get/ruby.html", tag: "Lookup", summary: "Get HTML Fragment with Ruby Annotations",
+			parameters: append(lookup,
+				apiparameter{name: "annotate", description: "What to annotate Korean with.", values: []string{scraper.AnnotateRomanisation, scraper.AnnotateHanja}},
+				detail,
+			),
+			contenttype: "text/html"},
+		{method: "GET", path: "/suggest", tag: "Lookup", summary: "Get Autocomplete Suggestions", parameters: []apiparameter{{name: "q", description: "Partially typed word. Empty while typing."}}, response: SuggestResponse{}},
+		{method: "POST", path: "/export/anki", tag: "Export", summary: "Export an Anki Deck", parameters: []apiparameter{dict, locale}, body: exportrequest{}, contenttype: "application/apkg"},
+		{method: "POST", path: "/export/csv", tag: "Export", summary: "Export a CSV Vocabulary List",
+			parameters: []apiparameter{dict, locale, columns, header, bom},
+			body:       exportrequest{}, contenttype: "text/csv"},
+		{method: "POST", path: "/export/tsv", tag: "Export", summary: "Export a TSV Vocabulary List",
+			parameters: []apiparameter{dict, locale, columns, header, bom},
+			body:       exportrequest{}, contenttype: "text/tab-separated-values"},
+		{method: "POST", path: "/export/html", tag: "Export", summary: "Export a printable HTML Vocabulary Sheet",
+			parameters: []apiparameter{dict, locale, quiz},
+			body:       exportrequest{}, contenttype: "text/html"},
+		{method: "POST", path: "/export/pdf", tag: "Export", summary: "Export a printable PDF Vocabulary Sheet",
+			parameters: []apiparameter{dict, locale, quiz},
+			body:       exportrequest{}, contenttype: "application/pdf"},
+		{method: "GET", path: "/openapi.json", tag: "General", summary: "Get OpenAPI Document", description: "This document.", contenttype: "application/json"},
+		{method: "GET", path: "/docs", tag: "General", summary: "Get API Documentation Page", contenttype: "text/html"},
+	}
+	if debugtoken != "" {
+		operations = append(operations,
+			apioperation{method: "GET", path: "/debug/schema", tag: "Debug", summary: "Get Upstream Schema Drift", response: SchemaReportResponse{}, authorised: true},
+			apioperation{method: "GET", path: "/debug/proxies", tag: "Debug", summary: "Get Proxy Statistics", response: ProxiesResponse{}, authorised: true},
+			apioperation{method: "GET", path: "/debug/vars", tag: "Debug", summary: "Get Metrics", description: "Counters published with expvar.", response: map[string]interface{}{}, authorised: true},
+		)
+	}
+	return operations
+}
+
+// Build the OpenAPI 3.1 document of the operations. JSON bodies are
+// described by the JSON Schemas of their Go types, as components named
+// after the types.
+func openapidocument(operations []apioperation) map[string]interface{} {
+	components := map[string]interface{}{"ErrorResponse": scraper.TypeSchema(ErrorResponse{})}
+	reference := func(value interface{}) map[string]interface{} {
+		valuetype := reflect.TypeOf(value)
+		if valuetype.Name() == "" {
+			return scraper.TypeSchema(value)
+		}
+		name := valuetype.Name()
+		components[name] = scraper.TypeSchema(value)
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	errorresponse := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}}},
+		}
+	}
+
+	paths := map[string]interface{}{}
+	for _, operation := range operations {
+		parameters := []interface{}{}
+		for _, parameter := range operation.parameters {
+			schema := map[string]interface{}{"type": "string"}
+			if parameter.kind != "" {
+				schema["type"] = parameter.kind
+			}
+			if len(parameter.values) > 0 {
+				schema["enum"] = parameter.values
+			}
+			parameters = append(parameters, map[string]interface{}{
+				"name":        parameter.name,
+				"in":          "query",
+				"description": parameter.description,
+				"required":    parameter.required,
+				"schema":      schema,
+			})
+		}
+
+		content := map[string]interface{}{operation.contenttype: map[string]interface{}{}}
+		if operation.response != nil {
+			content = map[string]interface{}{"application/json": map[string]interface{}{"schema": reference(operation.response)}}
+		} else if operation.contenttype == "image/png" || operation.contenttype == "application/pdf" || operation.contenttype == "application/apkg" {
+			content[operation.contenttype] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+		}
+		responses := map[string]interface{}{"200": map[string]interface{}{"description": "OK", "content": content}}
+		lookup := strings.HasPrefix(operation.path, "/get") || strings.HasPrefix(operation.path, "/export")
+		if lookup {
+			responses["400"] = errorresponse("Invalid parameters or body.")
+		}
+		if lookup || operation.path == "/suggest" {
+			responses["500"] = errorresponse("The word could not be looked up.")
+		}
+		if operation.path == "/get" {
+			responses["404"] = errorresponse("The word does not match the filter.")
+		}
+		if operation.authorised {
+			responses["401"] = errorresponse("Missing or wrong bearer token.")
+		}
+
+		document := map[string]interface{}{
+			"operationId": strings.ToLower(operation.method) + strings.NewReplacer("/", "_", ".", "_").Replace(strings.TrimSuffix(operation.path, "/")),
+			"tags":        []string{operation.tag},
+			"summary":     operation.summary,
+			"parameters":  parameters,
+			"responses":   responses,
+		}
+		if operation.description != "" {
+			document["description"] = operation.description
+		}
+		if operation.authorised {
+			document["security"] = []interface{}{map[string]interface{}{"debugToken": []string{}}}
+		}
+		if operation.body != nil {
+			document["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": reference(operation.body)}},
+			}
+		}
+		methods, _ := paths[operation.path].(map[string]interface{})
+		if methods == nil {
+			methods = map[string]interface{}{}
+			paths[operation.path] = methods
+		}
+		methods[strings.ToLower(operation.method)] = document
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "NaverDictionary",
+			"description": "Korean words from the Naver dictionaries, as JSON, chat messages, word cards and exports.",
+			"version":     fmt.Sprintf("%d", scraper.SchemaVersion),
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas":         components,
+			"securitySchemes": map[string]interface{}{"debugToken": map[string]interface{}{"type": "http", "scheme": "bearer"}},
+		},
+	}
+}
+
+// Check that every route of a router is documented, and every documented
+// operation is routed.
+func checkopenapi(routes gin.RoutesInfo, operations []apioperation) error {
+	routed := map[string]bool{}
+	for _, route := range routes {
+		routed[route.Method+" "+route.Path] = true
+	}
+	documented := map[string]bool{}
+	for _, operation := range operations {
+		documented[operation.method+" "+operation.path] = true
+	}
+	problems := []string{}
+	for route := range routed {
+		if !documented[route] {
+			problems = append(problems, route+" is not documented")
+		}
+	}
+	for operation := range documented {
+		if !routed[operation] {
+			problems = append(problems, operation+" is not routed")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		msg := fmt.Sprintf("OpenAPI document out of date: %s", strings.Join(problems, "; "))
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// Page that documents the API from /openapi.json, with forms to try it.
+//
+//go:embed docs.html
+var docspage []byte
+
+// Returns the OpenAPI Document
+func getopenapi(c *gin.Context) {
+	c.JSON(200, openapidocument(apioperations()))
+}
+
+// Returns the API Documentation Page
+func getdocs(c *gin.Context) {
+	c.Data(200, "text/html; charset=utf-8", docspage)
+}
@@ -0,0 +1,207 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"naverdictionary/scraper/scrapertest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIRoutes(t *testing.T) {
+	usefakenaver(t)
+	if errdocs := checkopenapi(SetupRouter().Routes(), apioperations()); errdocs != nil {
+		t.Error(errdocs)
+	}
+	usedebugtoken(t, "secret")
+	if errdocs := checkopenapi(SetupRouter().Routes(), apioperations()); errdocs != nil {
+		t.Errorf("with NAVERDICT_DEBUG_TOKEN: %v", errdocs)
+	}
+}
+
+func TestCheckOpenAPIDrift(t *testing.T) {
+	usefakenaver(t)
+	operations := apioperations()
+	operations = append(operations[1:], apioperation{method: "GET", path: "/missing"})
+	errdocs := checkopenapi(SetupRouter().Routes(), operations)
+	if errdocs == nil || !strings.Contains(errdocs.Error(), "GET / is not documented") || !strings.Contains(errdocs.Error(), "GET /missing is not routed") {
+		t.Errorf("checkopenapi() = %v; want GET / undocumented and GET /missing unrouted", errdocs)
+	}
+}
+
+func TestGetOpenAPI(t *testing.T) {
+	usefakenaver(t)
+	recorder := serve(SetupRouter(), "/openapi.json", nil)
+	document := map[string]interface{}{}
+	if errdecode := json.Unmarshal(recorder.Body.Bytes(), &document); recorder.Code != 200 || errdecode != nil {
+		t.Fatalf("GET /openapi.json = %d %s; want 200 with a JSON document", recorder.Code, recorder.Body)
+	}
+	paths, _ := document["paths"].(map[string]interface{})
+	if document["openapi"] != "3.1.0" || len(paths) == 0 {
+		t.Errorf("GET /openapi.json = %v; want an OpenAPI 3.1 document with paths", document)
+	}
+	for _, operation := range apioperations() {
+		methods, _ := paths[operation.path].(map[string]interface{})
+		if _, found := methods[strings.ToLower(operation.method)]; !found {
+			t.Errorf("GET /openapi.json has no %s %s", operation.method, operation.path)
+		}
+	}
+}
+
+func TestGetDocs(t *testing.T) {
+	usefakenaver(t)
+	recorder := serve(SetupRouter(), "/docs", nil)
+	contenttype := recorder.Header().Get("Content-Type")
+	if recorder.Code != 200 || !strings.HasPrefix(contenttype, "text/html") || !strings.Contains(recorder.Body.String(), "openapi.json") {
+		t.Errorf("GET /docs = %d %s; want 200 with the page that loads openapi.json", recorder.Code, contenttype)
+	}
+}
+
+// Every documented GET route answers each word of the fake server with
+// its documented content type, and JSON bodies match their schema.
+func TestDocumentedResponses(t *testing.T) {
+	usefakenaver(t)
+	usedebugtoken(t, "secret")
+	router := SetupRouter()
+	document := openapidocument(apioperations())
+	components := document["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, operation := range apioperations() {
+		if operation.method != "GET" {
+			continue
+		}
+		queries := []url.Values{{}}
+		for _, parameter := range operation.parameters {
+			if parameter.name == "word" {
+				queries = nil
+				for _, word := range scrapertest.Words() {
+					queries = append(queries, url.Values{"word": {word}})
+				}
+			}
+			if parameter.name == "q" {
+				queries = []url.Values{{"q": {"사"}}}
+			}
+		}
+		methods := document["paths"].(map[string]interface{})[operation.path].(map[string]interface{})
+		responses := methods["get"].(map[string]interface{})["responses"].(map[string]interface{})
+		content := responses["200"].(map[string]interface{})["content"].(map[string]interface{})
+		for _, query := range queries {
+			recorder := serve(router, operation.path, query)
+			request, _ := url.QueryUnescape(operation.path + "?" + query.Encode())
+			if recorder.Code != 200 {
+				t.Errorf("GET %s = %d %s; want 200", request, recorder.Code, recorder.Body)
+				continue
+			}
+			contenttype, _, _ := strings.Cut(recorder.Header().Get("Content-Type"), ";")
+			media, found := content[contenttype].(map[string]interface{})
+			if !found {
+				t.Errorf("GET %s has Content-Type %s; want one of %v", request, contenttype, content)
+				continue
+			}
+			schema, found := media["schema"].(map[string]interface{})
+			if contenttype != "application/json" || !found {
+				continue
+			}
+			var body interface{}
+			if errdecode := json.Unmarshal(recorder.Body.Bytes(), &body); errdecode != nil {
+				t.Errorf("GET %s = %s; want JSON: %v", request, recorder.Body, errdecode)
+				continue
+			}
+			for _, problem := range checkjsonschema(schema, components, body, "$") {
+				t.Errorf("GET %s: %s", request, problem)
+			}
+		}
+	}
+}
+
+// Check a decoded JSON value against the subset of JSON Schema that
+// scraper.TypeSchema generates, with references into components. Returns
+// the problems found, each prefixed with the path of its value.
+func checkjsonschema(schema map[string]interface{}, components map[string]interface{}, value interface{}, path string) []string {
+	if reference, found := schema["$ref"].(string); found {
+		component, _ := components[strings.TrimPrefix(reference, "#/components/schemas/")].(map[string]interface{})
+		if component == nil {
+			return []string{fmt.Sprintf("%s: unknown reference %s", path, reference)}
+		}
+		return checkjsonschema(component, components, value, path)
+	}
+	kind, _ := schema["type"].(string)
+	problems := []string{}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if kind != "" && kind != "object" {
+			return []string{fmt.Sprintf("%s is an object; want %s", path, kind)}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, found := value[name]; !found {
+				problems = append(problems, fmt.Sprintf("%s.%s is missing", path, name))
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, found := properties[name].(map[string]interface{})
+			if !found {
+				property, found = schema["additionalProperties"].(map[string]interface{})
+			}
+			if !found {
+				if properties != nil {
+					problems = append(problems, fmt.Sprintf("%s.%s is not in the schema", path, name))
+				}
+				continue
+			}
+			problems = append(problems, checkjsonschema(property, components, value[name], path+"."+name)...)
+		}
+	case []interface{}:
+		if kind != "" && kind != "array" {
+			return []string{fmt.Sprintf("%s is an array; want %s", path, kind)}
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range value {
+			if items != nil {
+				problems = append(problems, checkjsonschema(items, components, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		if kind != "" && kind != "string" {
+			problems = append(problems, fmt.Sprintf("%s is a string; want %s", path, kind))
+		}
+	case bool:
+		if kind != "" && kind != "boolean" {
+			problems = append(problems, fmt.Sprintf("%s is a boolean; want %s", path, kind))
+		}
+	case float64:
+		if kind == "integer" && value != float64(int64(value)) {
+			problems = append(problems, fmt.Sprintf("%s is %v; want an integer", path, value))
+		} else if kind != "" && kind != "integer" && kind != "number" {
+			problems = append(problems, fmt.Sprintf("%s is a number; want %s", path, kind))
+		}
+	case nil:
+		if kind != "" {
+			problems = append(problems, fmt.Sprintf("%s is null; want %s", path, kind))
+		}
+	}
+	return problems
+}
+
+func TestCheckJSONSchema(t *testing.T) {
+	schema := map[string]interface{}{"$ref": "#/components/schemas/Word"}
+	components := map[string]interface{}{"Word": map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"title": map[string]interface{}{"type": "string"}, "stars": map[string]interface{}{"type": "integer"}},
+		"required":   []string{"title", "stars"},
+	}}
+	var value interface{}
+	json.Unmarshal([]byte(`{"title": 1, "extra": true}`), &value)
+	got := checkjsonschema(schema, components, value, "$")
+	want := []string{"$.stars is missing", "$.extra is not in the schema", "$.title is a number; want string"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("checkjsonschema() = %q; want %q", got, want)
+	}
+}
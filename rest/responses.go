@@ -0,0 +1,40 @@
+package rest
+
+import "naverdictionary/scraper"
+
+// ErrorResponse is the JSON body of every error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// WelcomeResponse is the JSON body of the welcome page.
+type WelcomeResponse struct {
+	Message string `json:"message"` // In the locale.
+}
+
+// RawResponse is the JSON body of /get/entryinfo and /get/searchinfo, with
+// Naver's response as it is.
+type RawResponse struct {
+	Message map[string]interface{} `json:"message"`
+}
+
+// MessageResponse is the JSON body of /get/message.
+type MessageResponse struct {
+	Message string   `json:"message"`
+	Pages   []string `json:"pages,omitempty"` // Only with max_length.
+}
+
+// SuggestResponse is the JSON body of /suggest.
+type SuggestResponse struct {
+	Message []string `json:"message"`
+}
+
+// SchemaReportResponse is the JSON body of /debug/schema.
+type SchemaReportResponse struct {
+	Message scraper.SchemaReport `json:"message"`
+}
+
+// ProxiesResponse is the JSON body of /debug/proxies.
+type ProxiesResponse struct {
+	Message []scraper.ProxyStats `json:"message"`
+}
@@ -1,8 +1,16 @@
 package rest
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
 	"naverdictionary/scraper"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,23 +22,46 @@ func SetupRouter() *gin.Engine {
 	// Define routes
 	router.GET("/", welcome)                     // Welcome Page
 	router.GET("/get", get)                      // Get Dictionary Info
+	router.GET("/get/entry", getentry)           // Get Structured Entry
 	router.GET("/get/entryinfo", getentryinfo)   // Get Entry Info Raw
 	router.GET("/get/searchinfo", getsearchinfo) // Get Search Info RaW
 	router.GET("/get/message", getmessage)       // Get Message
+	router.GET("/get/card.png", getcard)         // Get Word Card
+	router.GET("/get/ruby.html", getruby)        // Get HTML Fragment with Ruby Annotations
+	router.GET("/suggest", suggest)              // Get Autocomplete Suggestions
+	router.POST("/export/anki", exportanki)      // Export an Anki Deck
+	router.POST("/export/csv", exportcsv)        // Export a CSV Vocabulary List
+	router.POST("/export/tsv", exporttsv)        // Export a TSV Vocabulary List
+	router.POST("/export/html", exporthtml)      // Export a printable HTML Vocabulary Sheet
+	router.POST("/export/pdf", exportpdf)        // Export a printable PDF Vocabulary Sheet
+	router.GET("/openapi.json", getopenapi)      // Get OpenAPI Document
+	router.GET("/docs", getdocs)                 // Get API Documentation Page
 
+	// Define debug routes, only behind a token as they expose the process
+	if debugtoken != "" {
+		debug := router.Group("/debug", requiretoken(debugtoken))
+		debug.GET("/schema", debugschema)               // Get Upstream Schema Drift
+		debug.GET("/proxies", debugproxies)             // Get Proxy Statistics
+		debug.GET("/vars", gin.WrapH(expvar.Handler())) // Get Metrics
+	}
+
+	// Every route must be in the OpenAPI document, see openapi_test.go.
 	return router
 }
 
 // StartServer initializes and starts the server
 func StartServer() {
+	errconfigure := configure()
+	if errconfigure != nil {
+		log.Fatal(errconfigure)
+	}
 	router := SetupRouter()
 	router.Run(":8080") // Listen on port 8080
 }
 
 func welcome(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"message": "Welcome to the Naver Scraper API!",
-	})
+	locale, _ := extractlocale(c)
+	c.JSON(200, WelcomeResponse{Message: scraper.Translate(locale, scraper.MessageApiWelcome)})
 }
 
 func extractword(c *gin.Context) (string, error) {
@@ -45,94 +76,522 @@ func extractword(c *gin.Context) (string, error) {
 	return word, nil
 }
 
+func extractprovider(c *gin.Context) (scraper.Provider, error) {
+	dict := c.Query("dict") // Get the optional "dict" query parameter
+	return scraper.GetProvider(dict)
+}
+
+// Extract the locale from the "locale" query parameter, or else the Accept-Language header.
+func extractlocale(c *gin.Context) (string, error) {
+	locale := c.Query("locale") // Get the optional "locale" query parameter
+	if locale == "" {
+		return scraper.MatchLocale(c.GetHeader("Accept-Language")), nil
+	}
+	primary, _, _ := strings.Cut(strings.ToLower(locale), "-") // The primary subtag, e.g. ko of ko-KR or ko_KR
+	primary, _, _ = strings.Cut(primary, "_")
+	matched := scraper.MatchLocale(locale)
+	if primary == matched { // Not just the DefaultLocale as a fallback.
+		return matched, nil
+	}
+	msg := fmt.Sprintf("unsupported locale %q, want one of %s", locale, strings.Join(scraper.Locales(), ", "))
+	return scraper.DefaultLocale, errors.New(msg)
+}
+
+// Translate errors that are replies to the user, e.g. the welcome message.
+func localiseerror(err error, locale string) string {
+	if errors.Is(err, scraper.ErrWelcome) {
+		return scraper.Translate(locale, scraper.MessageWelcome)
+	}
+	return err.Error()
+}
+
+// Extract the detail from the "detail" query parameter. Without it, built-in
+// formats use the DefaultDetail and templates get the whole entry.
+func extractdetail(c *gin.Context) (scraper.Detail, error) {
+	detail := c.Query("detail") // Get the optional "detail" query parameter
+	if detail == "" {
+		return "", nil
+	}
+	return scraper.ParseDetail(detail)
+}
+
+func extractrenderer(c *gin.Context) (scraper.Renderer, error) {
+	format := c.Query("format") // Get the optional "format" query parameter
+	return scraper.GetRenderer(format)
+}
+
+func extractfilter(c *gin.Context) (scraper.Filter, error) {
+	filter := scraper.Filter{}
+	if levels := c.Query("topik"); levels != "" { // Get the optional "topik" query parameter, e.g. 1,2
+		for _, level := range strings.Split(levels, ",") {
+			parsed, errparse := scraper.ParseTopikLevel(level)
+			if errparse != nil {
+				return filter, errparse
+			}
+			filter.Levels = append(filter.Levels, parsed)
+		}
+	}
+	if importance := c.Query("min_importance"); importance != "" { // Get the optional "min_importance" query parameter
+		parsed, errparse := strconv.Atoi(importance)
+		if errparse != nil || parsed < 0 || parsed > 3 {
+			msg := fmt.Sprintf("invalid 'min_importance' parameter %q, want 0 to 3", importance)
+			return filter, errors.New(msg)
+		}
+		filter.MinImportance = parsed
+	}
+	return filter, nil
+}
+
 // Returns the Dictionary Info
 func get(c *gin.Context) {
 	word, errword := extractword(c) // Extract the word from the query parameter
 	if errword != nil {
-		c.JSON(400, gin.H{
-			"error": errword.Error(),
-		})
+		c.JSON(400, ErrorResponse{Error: errword.Error()})
+		return
+	}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		c.JSON(400, ErrorResponse{Error: errprovider.Error()})
+		return
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		c.JSON(400, ErrorResponse{Error: errlocale.Error()})
 		return
 	}
 
-	dictinfo, errget := scraper.Get(word) // Pass the word to the scraper
+	filter, errfilter := extractfilter(c) // Extract the filter from the query parameters
+	if errfilter != nil {
+		c.JSON(400, ErrorResponse{Error: errfilter.Error()})
+		return
+	}
+
+	entry, errget := scraper.GetEntryFrom(provider, word) // Pass the word to the scraper
 	if errget != nil {
-		c.JSON(500, gin.H{
-			"error": errget.Error(),
-		})
+		c.JSON(500, ErrorResponse{Error: localiseerror(errget, locale)})
+		return
+	}
+	dictinfo := entry.DictInfoIn(locale)
+	if !filter.Matches(dictinfo) {
+		c.JSON(404, ErrorResponse{Error: scraper.Translate(locale, scraper.MessageNoMatch)})
+		return
+	}
+
+	c.JSON(200, scraper.NewLookupResponse(dictinfo))
+}
+
+// Returns the Structured Entry
+func getentry(c *gin.Context) {
+	word, errword := extractword(c) // Extract the word from the query parameter
+	if errword != nil {
+		c.JSON(400, ErrorResponse{Error: errword.Error()})
+		return
+	}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		c.JSON(400, ErrorResponse{Error: errprovider.Error()})
+		return
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		c.JSON(400, ErrorResponse{Error: errlocale.Error()})
+		return
+	}
+
+	entry, errentry := scraper.GetEntryFrom(provider, word) // Pass the word to the scraper
+	if errentry != nil {
+		c.JSON(500, ErrorResponse{Error: localiseerror(errentry, locale)})
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"message": dictinfo,
-	})
+	c.JSON(200, scraper.NewEntryResponse(entry))
 }
 
 // Returns the Raw Entry Info
 func getentryinfo(c *gin.Context) {
 	word, errword := extractword(c) // Extract the word from the query parameter
 	if errword != nil {
-		c.JSON(400, gin.H{
-			"error": errword.Error(),
-		})
+		c.JSON(400, ErrorResponse{Error: errword.Error()})
+		return
+	}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		c.JSON(400, ErrorResponse{Error: errprovider.Error()})
+		return
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		c.JSON(400, ErrorResponse{Error: errlocale.Error()})
 		return
 	}
 
-	entryinfo, errentryinfo := scraper.GetEntryInfoRaw(word) // Pass the word to the scraper
+	entryinfo, errentryinfo := scraper.GetEntryInfoRawFrom(provider, word) // Pass the word to the scraper
 	if errentryinfo != nil {
-		c.JSON(500, gin.H{
-			"error": errentryinfo.Error(),
-		})
+		c.JSON(500, ErrorResponse{Error: localiseerror(errentryinfo, locale)})
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"message": entryinfo,
-	})
+	c.JSON(200, RawResponse{Message: entryinfo})
 }
 
 // Returns the Raw Search Info
 func getsearchinfo(c *gin.Context) {
 	word, errword := extractword(c) // Extract the word from the query parameter
 	if errword != nil {
-		c.JSON(400, gin.H{
-			"error": errword.Error(),
-		})
+		c.JSON(400, ErrorResponse{Error: errword.Error()})
+		return
+	}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		c.JSON(400, ErrorResponse{Error: errprovider.Error()})
+		return
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		c.JSON(400, ErrorResponse{Error: errlocale.Error()})
 		return
 	}
 
-	searchinfo, errsearchinfo := scraper.GetSearchInfoRaw(word) // Pass the word to the scraper
+	searchinfo, errsearchinfo := scraper.GetSearchInfoRawFrom(provider, word) // Pass the word to the scraper
 	if errsearchinfo != nil {
-		c.JSON(500, gin.H{
-			"error": errsearchinfo.Error(),
-		})
+		c.JSON(500, ErrorResponse{Error: localiseerror(errsearchinfo, locale)})
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"message": searchinfo,
-	})
+	c.JSON(200, RawResponse{Message: searchinfo})
 }
 
 // Returns the Message
 func getmessage(c *gin.Context) {
 	word, errword := extractword(c) // Extract the word from the query parameter
 	if errword != nil {
-		c.JSON(400, gin.H{
-			"error": errword.Error(),
-		})
+		c.JSON(400, ErrorResponse{Error: errword.Error()})
+		return
+	}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		c.JSON(400, ErrorResponse{Error: errprovider.Error()})
+		return
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		c.JSON(400, ErrorResponse{Error: errlocale.Error()})
+		return
+	}
+
+	renderer, errrenderer := extractrenderer(c) // Extract the format from the query parameter
+	if errrenderer != nil {
+		c.JSON(400, ErrorResponse{Error: errrenderer.Error()})
+		return
+	}
+
+	detail, errdetail := extractdetail(c) // Extract the detail from the query parameter
+	if errdetail != nil {
+		c.JSON(400, ErrorResponse{Error: errdetail.Error()})
+		return
+	}
+
+	maxlength := 0
+	if length := c.Query("max_length"); length != "" { // Get the optional "max_length" query parameter
+		parsed, errparse := strconv.Atoi(length)
+		if errparse != nil || parsed < 1 {
+			c.JSON(400, ErrorResponse{Error: fmt.Sprintf("invalid 'max_length' parameter %q, want a positive number", length)})
+			return
+		}
+		maxlength = parsed
+	}
+
+	entry, errentry := scraper.GetEntryFrom(provider, word) // Pass the word to the scraper
+	if errentry != nil {
+		c.JSON(500, ErrorResponse{Error: localiseerror(errentry, locale)})
+		return
+	}
+
+	renderer = scraper.Localise(scraper.WithDetail(renderer, detail), locale)
+	message, errrender := scraper.RenderEntry(renderer, entry)
+	if errrender != nil {
+		c.JSON(500, ErrorResponse{Error: errrender.Error()})
+		return
+	}
+	if maxlength == 0 {
+		c.JSON(200, MessageResponse{Message: message})
+		return
+	}
+	c.JSON(200, MessageResponse{Message: message, Pages: scraper.Paginate(renderer, entry, maxlength)})
+}
+
+// Returns the Word Card as a PNG image
+func getcard(c *gin.Context) {
+	word, errword := extractword(c) // Extract the word from the query parameter
+	if errword != nil {
+		c.JSON(400, ErrorResponse{Error: errword.Error()})
+		return
+	}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		c.JSON(400, ErrorResponse{Error: errprovider.Error()})
+		return
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		c.JSON(400, ErrorResponse{Error: errlocale.Error()})
 		return
 	}
 
-	message, errmessage := scraper.GetMessage(word) // Pass the word to the scraper
-	if errmessage != nil {
-		c.JSON(500, gin.H{
-			"error": errmessage.Error(),
-		})
+	entry, errentry := scraper.GetEntryFrom(provider, word) // Pass the word to the scraper
+	if errentry != nil {
+		c.JSON(500, ErrorResponse{Error: localiseerror(errentry, locale)})
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"message": message,
-	})
+	card, errcard := scraper.RenderCardIn(locale, entry)
+	if errcard != nil {
+		c.JSON(500, ErrorResponse{Error: errcard.Error()})
+		return
+	}
+	c.Data(200, "image/png", card)
+}
+
+// Returns the Entry as an HTML fragment with ruby annotations
+func getruby(c *gin.Context) {
+	word, errword := extractword(c) // Extract the word from the query parameter
+	if errword != nil {
+		c.JSON(400, ErrorResponse{Error: errword.Error()})
+		return
+	}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		c.JSON(400, ErrorResponse{Error: errprovider.Error()})
+		return
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		c.JSON(400, ErrorResponse{Error: errlocale.Error()})
+		return
+	}
+	renderer, errrenderer := scraper.GetRubyRenderer(c.Query("annotate")) // Get the optional "annotate" query parameter
+	if errrenderer != nil {
+		c.JSON(400, ErrorResponse{Error: errrenderer.Error()})
+		return
+	}
+	detail, errdetail := scraper.ParseDetail(c.Query("detail")) // Get the optional "detail" query parameter
+	if errdetail != nil {
+		c.JSON(400, ErrorResponse{Error: errdetail.Error()})
+		return
+	}
+
+	entry, errentry := scraper.GetEntryFrom(provider, word) // Pass the word to the scraper
+	if errentry != nil {
+		c.JSON(500, ErrorResponse{Error: localiseerror(errentry, locale)})
+		return
+	}
+
+	c.Data(200, "text/html; charset=utf-8", []byte(renderer.RenderDetail(locale, detail, entry)))
+}
+
+// Returns Autocomplete Suggestions for a partially typed word
+func suggest(c *gin.Context) {
+	query := c.Query("q") // Empty queries are expected while typing, so they are not an error.
+
+	suggestions, local, errsuggest := scraper.GetSuggestions(query)
+	if errsuggest != nil {
+		c.JSON(500, ErrorResponse{Error: errsuggest.Error()})
+		return
+	}
+
+	// Let browsers and proxies absorb repeated keystrokes for the same prefix,
+	// but not keep local suggestions once Naver is back.
+	if local {
+		c.Header("Cache-Control", "no-store")
+	} else {
+		c.Header("Cache-Control", "public, max-age=300")
+	}
+	c.JSON(200, SuggestResponse{Message: suggestions})
+}
+
+// Maximum number of words in one export.
+const maxexportwords = 200
+
+// exportrequest is the JSON body of an export, with words to look up or
+// entries already looked up with /get/entry.
+type exportrequest struct {
+	Deck    string          `json:"deck,omitempty"`
+	Title   string          `json:"title,omitempty"`
+	Words   []string        `json:"words,omitempty"`
+	Entries []scraper.Entry `json:"entries,omitempty"`
+}
+
+// Extract the words of an export from the JSON body and look them up.
+// Returns the HTTP status of an error.
+func extractexport(c *gin.Context) (exportrequest, []scraper.Entry, int, error) {
+	request := exportrequest{}
+	provider, errprovider := extractprovider(c) // Extract the dictionary from the query parameter
+	if errprovider != nil {
+		return request, nil, 400, errprovider
+	}
+	locale, errlocale := extractlocale(c) // Extract the locale from the query parameter or header
+	if errlocale != nil {
+		return request, nil, 400, errlocale
+	}
+
+	errbind := c.ShouldBindJSON(&request)
+	if errbind != nil {
+		return request, nil, 400, errors.New("invalid export body: " + errbind.Error())
+	}
+	count := len(request.Words) + len(request.Entries)
+	if count == 0 || count > maxexportwords {
+		msg := fmt.Sprintf("export needs 1 to %d 'words' or 'entries', got %d", maxexportwords, count)
+		return request, nil, 400, errors.New(msg)
+	}
+
+	entries := request.Entries
+	provider = scraper.InLane(provider, scraper.Batch) // Let lookups of waiting users go first
+	for _, word := range request.Words {
+		entry, errentry := scraper.GetEntryFrom(provider, word) // Pass the word to the scraper
+		if errentry != nil {
+			return request, nil, 500, errors.New(word + ": " + localiseerror(errentry, locale))
+		}
+		entries = append(entries, entry)
+	}
+	return request, entries, 200, nil
+}
+
+// Returns an Anki Deck of the requested words
+func exportanki(c *gin.Context) {
+	request, entries, status, errexport := extractexport(c) // Extract and look up the words in the body
+	if errexport != nil {
+		c.JSON(status, ErrorResponse{Error: errexport.Error()})
+		return
+	}
+
+	var apkg bytes.Buffer
+	errwrite := scraper.WriteAnki(&apkg, scraper.AnkiDeck{Name: request.Deck, Entries: entries})
+	if errwrite != nil {
+		c.JSON(500, ErrorResponse{Error: errwrite.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="naverdict.apkg"`)
+	c.Data(200, "application/apkg", apkg.Bytes())
+}
+
+// Returns a CSV Vocabulary List of the requested words
+func exportcsv(c *gin.Context) {
+	exportvocabulary(c, scraper.CSV, "text/csv; charset=utf-8", "naverdict.csv")
+}
+
+// Returns a TSV Vocabulary List of the requested words
+func exporttsv(c *gin.Context) {
+	exportvocabulary(c, scraper.TSV, "text/tab-separated-values; charset=utf-8", "naverdict.tsv")
+}
+
+// Returns a Vocabulary List of the requested words in a format
+func exportvocabulary(c *gin.Context, format scraper.VocabularyFormat, contenttype string, filename string) {
+	if columns := c.Query("columns"); columns != "" { // Get the optional "columns" query parameter, e.g. term,gloss
+		parsed, errparse := scraper.ParseVocabularyColumns(columns)
+		if errparse != nil {
+			c.JSON(400, ErrorResponse{Error: errparse.Error()})
+			return
+		}
+		format.Columns = parsed
+	}
+	if header := c.Query("header"); header != "" { // Get the optional "header" query parameter
+		parsed, errparse := strconv.ParseBool(header)
+		if errparse != nil {
+			c.JSON(400, ErrorResponse{Error: fmt.Sprintf("invalid 'header' parameter %q, want true or false", header)})
+			return
+		}
+		format.Header = parsed
+	}
+	if bom := c.Query("bom"); bom != "" { // Get the optional "bom" query parameter
+		parsed, errparse := strconv.ParseBool(bom)
+		if errparse != nil {
+			c.JSON(400, ErrorResponse{Error: fmt.Sprintf("invalid 'bom' parameter %q, want true or false", bom)})
+			return
+		}
+		format.ByteOrderMark = parsed
+	}
+
+	_, entries, status, errexport := extractexport(c) // Extract and look up the words in the body
+	if errexport != nil {
+		c.JSON(status, ErrorResponse{Error: errexport.Error()})
+		return
+	}
+
+	var vocabulary bytes.Buffer
+	errwrite := format.Write(&vocabulary, entries)
+	if errwrite != nil {
+		c.JSON(500, ErrorResponse{Error: errwrite.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(200, contenttype, vocabulary.Bytes())
+}
+
+// Returns a printable HTML Vocabulary Sheet of the requested words
+func exporthtml(c *gin.Context) {
+	exportsheet(c, scraper.VocabularySheet.WriteHTML, "text/html; charset=utf-8", "naverdict.html")
+}
+
+// Returns a printable PDF Vocabulary Sheet of the requested words
+func exportpdf(c *gin.Context) {
+	exportsheet(c, scraper.VocabularySheet.WritePDF, "application/pdf", "naverdict.pdf")
+}
+
+// Returns a Vocabulary Sheet of the requested words, written by a method of
+// VocabularySheet
+func exportsheet(c *gin.Context, write func(scraper.VocabularySheet, io.Writer) error, contenttype string, filename string) {
+	quiz := false
+	if value := c.Query("quiz"); value != "" { // Get the optional "quiz" query parameter
+		parsed, errparse := strconv.ParseBool(value)
+		if errparse != nil {
+			c.JSON(400, ErrorResponse{Error: fmt.Sprintf("invalid 'quiz' parameter %q, want true or false", value)})
+			return
+		}
+		quiz = parsed
+	}
+
+	request, entries, status, errexport := extractexport(c) // Extract and look up the words in the body
+	if errexport != nil {
+		c.JSON(status, ErrorResponse{Error: errexport.Error()})
+		return
+	}
+	locale, _ := extractlocale(c) // Already checked by extractexport
+
+	var sheet bytes.Buffer
+	errwrite := write(scraper.VocabularySheet{Title: request.Title, Entries: entries, Quiz: quiz, Locale: locale}, &sheet)
+	if errwrite != nil {
+		c.JSON(500, ErrorResponse{Error: errwrite.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(200, contenttype, sheet.Bytes())
+}
+
+// Answer 401 to requests without the bearer token.
+func requiretoken(token string) gin.HandlerFunc {
+	want := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), want) != 1 {
+			c.AbortWithStatusJSON(401, ErrorResponse{Error: "missing or wrong bearer token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Returns the Upstream Schema Violations counted so far
+func debugschema(c *gin.Context) {
+	c.JSON(200, SchemaReportResponse{Message: scraper.DefaultSchemaMonitor.Report()})
+}
+
+// Returns the Statistics of each Proxy
+func debugproxies(c *gin.Context) {
+	stats := []scraper.ProxyStats{}
+	if proxypool != nil {
+		stats = proxypool.Stats()
+	}
+	c.JSON(200, ProxiesResponse{Message: stats})
 }
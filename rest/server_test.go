@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"encoding/json"
+	"naverdictionary/scraper"
+	"naverdictionary/scraper/scrapertest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Send every Naver request of the test to a fake server, without waiting
+// for the rate limiter.
+func usefakenaver(t *testing.T) *scrapertest.Server {
+	gin.SetMode(gin.TestMode)
+	server := scrapertest.NewServer()
+	restore := server.Install()
+	limiter := scraper.DefaultClient.Limiter
+	scraper.DefaultClient.Limiter = nil
+	t.Cleanup(func() {
+		scraper.DefaultClient.Limiter = limiter
+		restore()
+		server.Close()
+	})
+	return server
+}
+
+// Serve the debug routes with a token for the rest of the test.
+func usedebugtoken(t *testing.T, token string) {
+	saved := debugtoken
+	debugtoken = token
+	t.Cleanup(func() { debugtoken = saved })
+}
+
+// Send a GET request to the router and record the response.
+func serve(router *gin.Engine, path string, query url.Values) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path+"?"+query.Encode(), nil)
+	if debugtoken != "" {
+		request.Header.Set("Authorization", "Bearer "+debugtoken)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGetMessageTemplateError(t *testing.T) {
+	usefakenaver(t)
+	// The sample entry templates are checked with has a third gloss, 사랑 does not.
+	renderer, error := scraper.NewTemplateRenderer("thirdgloss", "{{index .Glosses 2}}", nil)
+	if error != nil {
+		t.Fatalf("NewTemplateRenderer() = %q; want no error", error)
+	}
+	scraper.RegisterRenderer(renderer)
+
+	recorder := serve(SetupRouter(), "/get/message", url.Values{"word": {"사랑"}, "format": {"thirdgloss"}})
+	response := ErrorResponse{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	if recorder.Code != 500 || response.Error == "" {
+		t.Errorf("GET /get/message with a failing template = %d %s; want 500 with an error", recorder.Code, recorder.Body)
+	}
+}
+
+func TestSuggestCacheControl(t *testing.T) {
+	server := usefakenaver(t)
+	router := SetupRouter()
+	recorder := serve(router, "/suggest", url.Values{"q": {"사"}})
+	if got := recorder.Header().Get("Cache-Control"); recorder.Code != 200 || got != "public, max-age=300" {
+		t.Errorf("GET /suggest = %d with Cache-Control %q; want 200 with %q", recorder.Code, got, "public, max-age=300")
+	}
+
+	// A query not cached yet falls back to the headwords seen so far.
+	server.Inject(scrapertest.Fault{Path: "/koen/ac", Status: http.StatusServiceUnavailable})
+	scraper.AddHeadwords([]string{"사랑"})
+	recorder = serve(router, "/suggest", url.Values{"q": {"사ㄹ"}})
+	if got := recorder.Header().Get("Cache-Control"); recorder.Code != 200 || got != "no-store" {
+		t.Errorf("GET /suggest with Naver down = %d with Cache-Control %q; want 200 with %q", recorder.Code, got, "no-store")
+	}
+}
+
+func TestDebugToken(t *testing.T) {
+	usefakenaver(t)
+	if recorder := serve(SetupRouter(), "/debug/vars", nil); recorder.Code != 404 {
+		t.Errorf("GET /debug/vars without NAVERDICT_DEBUG_TOKEN = %d; want 404", recorder.Code)
+	}
+
+	usedebugtoken(t, "secret")
+	router := SetupRouter()
+	if recorder := serve(router, "/debug/vars", nil); recorder.Code != 200 {
+		t.Errorf("GET /debug/vars with the token = %d; want 200", recorder.Code)
+	}
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		request := httptest.NewRequest(http.MethodGet, "/debug/schema", nil)
+		request.Header.Set("Authorization", header)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != 401 {
+			t.Errorf("GET /debug/schema with Authorization %q = %d; want 401", header, recorder.Code)
+		}
+	}
+}
+
+func TestExtractLocale(t *testing.T) {
+	tests := map[string]string{
+		"ko":      "ko",
+		"ko-KR":   "ko",
+		"ko_KR":   "ko",
+		"EN":      "en",
+		"english": "",
+		"enx":     "",
+		"kor":     "",
+		"fr":      "",
+	}
+	for locale, want := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/?"+url.Values{"locale": {locale}}.Encode(), nil)
+		got, errlocale := extractlocale(c)
+		if want == "" && errlocale == nil {
+			t.Errorf("extractlocale(%q) = %q; want error", locale, got)
+		}
+		if want != "" && (got != want || errlocale != nil) {
+			t.Errorf("extractlocale(%q) = %q, %v; want %q", locale, got, errlocale, want)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package scraper
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnkiDeck is a deck of Entries to export as an Anki package (.apkg), one
+// note and card per Entry.
+type AnkiDeck struct {
+	Name    string // Deck name, e.g. NaverDict::Week 1 for a subdeck.
+	Entries []Entry
+	Audio   AnkiAudio // Optional.
+	Created time.Time // Creation time of the notes, or now if zero.
+}
+
+// AnkiAudio finds the pronunciation audio of an Entry, e.g. an MP3 file.
+// Returns an empty name if there is none.
+type AnkiAudio func(entry Entry) (name string, data []byte, err error)
+
+// Deck name used when none is given.
+const DefaultAnkiDeckName = "NaverDict"
+
+// The note type of exported words. Its id is fixed, so that notes
+// exported at different times share one note type in Anki.
+const (
+	ankimodelid   = int64(1704067200000)
+	ankimodelname = "NaverDict Korean"
+)
+
+// Fields of the note type, in order.
+var ankifields = []string{"Korean", "Hanja", "Glosses", "Pronunciation", "Audio", "Senses"}
+
+const ankifront = `<div class="korean">{{Korean}}</div>
+{{#Hanja}}<div class="hanja">{{Hanja}}</div>{{/Hanja}}`
+
+const ankiback = `{{FrontSide}}
+<hr id="answer">
+<div class="glosses">{{Glosses}}</div>
+<div class="pronunciation">{{Pronunciation}} {{Audio}}</div>
+<div class="senses">{{Senses}}</div>`
+
+const ankicss = `.card { font-family: sans-serif; font-size: 20px; text-align: center; }
+.korean { font-size: 40px; }
+.hanja { color: #666; }
+.pronunciation { color: #666; font-size: 16px; }
+.senses { text-align: left; font-size: 16px; }
+.partofspeech { font-style: italic; margin-top: 8px; }
+.example { color: #444; }`
+
+// Schema of an Anki collection, version 11, as read by every Anki release.
+var ankischema = []sqlitetable{
+	{
+		name:       "col",
+		sql:        "CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)",
+		rowidalias: true,
+	},
+	{
+		name:       "notes",
+		sql:        "CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null)",
+		rowidalias: true,
+		indexes: []sqliteindex{
+			{"ix_notes_usn", "CREATE INDEX ix_notes_usn on notes (usn)", []int{4}},
+			{"ix_notes_csum", "CREATE INDEX ix_notes_csum on notes (csum)", []int{8}},
+		},
+	},
+	{
+		name:       "cards",
+		sql:        "CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null)",
+		rowidalias: true,
+		indexes: []sqliteindex{
+			{"ix_cards_usn", "CREATE INDEX ix_cards_usn on cards (usn)", []int{5}},
+			{"ix_cards_nid", "CREATE INDEX ix_cards_nid on cards (nid)", []int{1}},
+			{"ix_cards_sched", "CREATE INDEX ix_cards_sched on cards (did, queue, due)", []int{2, 7, 8}},
+		},
+	},
+	{
+		name:       "revlog",
+		sql:        "CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null)",
+		rowidalias: true,
+		indexes: []sqliteindex{
+			{"ix_revlog_usn", "CREATE INDEX ix_revlog_usn on revlog (usn)", []int{2}},
+			{"ix_revlog_cid", "CREATE INDEX ix_revlog_cid on revlog (cid)", []int{1}},
+		},
+	},
+	{
+		name: "graves",
+		sql:  "CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null)",
+	},
+}
+
+// Write a deck as an Anki package.
+func WriteAnki(writer io.Writer, deck AnkiDeck) error {
+	if deck.Name == "" {
+		deck.Name = DefaultAnkiDeckName
+	}
+	created := deck.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	modified := created.UnixMilli()
+	deckid := ankideckid(deck.Name)
+
+	// Notes and cards, skipping repeated words.
+	media := map[string][]byte{}
+	medianames := []string{}
+	notes, cards := [][]interface{}{}, [][]interface{}{}
+	seen := map[string]bool{}
+	for _, entry := range deck.Entries {
+		guid := ankiguid(entry)
+		if seen[guid] {
+			continue
+		}
+		seen[guid] = true
+
+		audio := ""
+		if deck.Audio != nil {
+			name, data, erraudio := deck.Audio(entry)
+			if erraudio != nil {
+				msg := fmt.Sprintf("Cannot get audio of %s: %v", entry.Title, erraudio)
+				return errors.New(msg)
+			}
+			name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+			if name != "" && name != "." && name != "/" {
+				if _, found := media[name]; !found {
+					medianames = append(medianames, name)
+				}
+				media[name] = data
+				audio = "[sound:" + name + "]"
+			}
+		}
+
+		id := modified + int64(len(notes))
+		checksum := sha1.Sum([]byte(entry.Title))
+		csum, _ := strconv.ParseInt(hex.EncodeToString(checksum[:4]), 16, 64)
+		fields := AnkiFields(entry)
+		fields[4] = audio
+		tags := []string{"naverdict"}
+		if entry.TopikLevel != TopikNone {
+			tags = append(tags, "topik_"+entry.TopikLevel.String())
+		}
+		notes = append(notes, []interface{}{
+			id, guid, ankimodelid, modified / 1000, int64(-1), " " + strings.Join(tags, " ") + " ",
+			strings.Join(fields, "\x1f"), entry.Title, csum, int64(0), "",
+		})
+		cards = append(cards, []interface{}{
+			id, id, deckid, int64(0), modified / 1000, int64(-1),
+			int64(0), int64(0), int64(len(cards) + 1), int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), "",
+		})
+	}
+
+	collection, errcollection := ankicollection(deck.Name, deckid, created)
+	if errcollection != nil {
+		return errcollection
+	}
+	tables := make([]sqlitetable, len(ankischema))
+	copy(tables, ankischema)
+	tables[0].rows = [][]interface{}{collection}
+	tables[1].rows = notes
+	tables[2].rows = cards
+	database, errdatabase := writesqlite(tables)
+	if errdatabase != nil {
+		return errdatabase
+	}
+
+	// The package is a zip of the collection, the media files named by
+	// number, and a media file mapping numbers to names.
+	archive := zip.NewWriter(writer)
+	add := func(name string, data []byte) error {
+		filewriter, errcreate := archive.Create(name)
+		if errcreate != nil {
+			return errcreate
+		}
+		_, errwrite := filewriter.Write(data)
+		return errwrite
+	}
+	errcollection = add("collection.anki2", database)
+	if errcollection != nil {
+		return errcollection
+	}
+	mediamap := map[string]string{}
+	for i, name := range medianames {
+		mediamap[strconv.Itoa(i)] = name
+		errmedia := add(strconv.Itoa(i), media[name])
+		if errmedia != nil {
+			return errmedia
+		}
+	}
+	mediajson, _ := json.Marshal(mediamap)
+	errmedia := add("media", mediajson)
+	if errmedia != nil {
+		return errmedia
+	}
+	return archive.Close()
+}
+
+// The fields of the note of an Entry, as HTML. The Audio field is empty.
+func AnkiFields(entry Entry) []string {
+	glosses := make([]string, len(entry.Glosses))
+	for i, gloss := range entry.Glosses {
+		glosses[i] = EscapeHTML(fmt.Sprintf("%d.%s", i+1, gloss))
+	}
+
+	senses := map[int]Sense{}
+	for _, sense := range entry.Senses {
+		senses[sense.Number] = sense
+	}
+	groups := entry.Groups
+	if groups == nil {
+		groups = GroupSenses(entry.Senses)
+	}
+	var html strings.Builder
+	for _, group := range groups {
+		partofspeech := group.PartOfSpeech
+		if group.PartOfSpeechEnglish != "" {
+			partofspeech += " (" + group.PartOfSpeechEnglish + ")"
+		}
+		if partofspeech != "" {
+			html.WriteString(`<div class="partofspeech">` + EscapeHTML(partofspeech) + `</div>`)
+		}
+		html.WriteString("<ol>")
+		for _, number := range group.Senses {
+			sense := senses[number]
+			lines := []string{}
+			if sense.Gloss != "" {
+				lines = append(lines, "<b>"+EscapeHTML(sense.Gloss)+"</b>")
+			}
+			for _, definition := range []string{sense.Definition, sense.KoreanDefinition} {
+				if definition != "" {
+					lines = append(lines, EscapeHTML(definition))
+				}
+			}
+			for _, example := range sense.Examples {
+				lines = append(lines, `<span class="example">`+example.Render(EscapeHTML, TelegramHTML.Wrap)+`</span>`)
+			}
+			html.WriteString(fmt.Sprintf(`<li value="%d">%s</li>`, sense.Number, strings.Join(lines, "<br>")))
+		}
+		html.WriteString("</ol>")
+	}
+
+	return []string{
+		EscapeHTML(entry.Title),
+		EscapeHTML(entry.Hanja),
+		strings.Join(glosses, " "),
+		EscapeHTML(Buildsentence("", []string{entry.Romanisation, entry.Pronunciation})),
+		"",
+		html.String(),
+	}
+}
+
+// A stable note id of an Entry, so that importing a word again updates its
+// note instead of adding another. Homographs are told apart by their entry
+// id, when known.
+func ankiguid(entry Entry) string {
+	hash := fnv.New64a()
+	hash.Write([]byte("naverdict\x1f" + entry.Title + "\x1f" + entry.Hanja + "\x1f" + entry.ID))
+	return strconv.FormatUint(hash.Sum64(), 36)
+}
+
+// A stable deck id of a deck name, so that exports into the same deck merge.
+func ankideckid(name string) int64 {
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	return int64(1)<<40 | int64(hash.Sum32())
+}
+
+// The row of the col table, with the note type, the deck and the default
+// deck options.
+func ankicollection(deckname string, deckid int64, created time.Time) ([]interface{}, error) {
+	modified := created.UnixMilli()
+	fields := make([]map[string]interface{}, len(ankifields))
+	for i, name := range ankifields {
+		fields[i] = map[string]interface{}{
+			"name": name, "ord": i, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []string{},
+		}
+	}
+	model := map[string]interface{}{
+		"id": ankimodelid, "name": ankimodelname, "type": 0, "mod": modified / 1000, "usn": -1, "sortf": 0, "did": deckid,
+		"tmpls": []map[string]interface{}{{
+			"name": "Recognition", "ord": 0, "qfmt": ankifront, "afmt": ankiback, "did": nil, "bqfmt": "", "bafmt": "",
+		}},
+		"flds": fields, "css": ankicss, "tags": []string{}, "vers": []int{},
+		"req":       []interface{}{[]interface{}{0, "any", []int{0}}},
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+	}
+	deck := func(id int64, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "name": name, "mod": modified / 1000, "usn": -1, "desc": "", "dyn": 0, "conf": 1, "collapsed": false,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+			"extendNew": 10, "extendRev": 50,
+		}
+	}
+	options := map[string]interface{}{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+		"new":   map[string]interface{}{"bury": true, "delays": []float64{1, 10}, "initialFactor": 2500, "ints": []int{1, 4, 7}, "order": 1, "perDay": 20, "separate": true},
+		"rev":   map[string]interface{}{"bury": true, "ease4": 1.3, "fuzz": 0.05, "ivlFct": 1, "maxIvl": 36500, "minSpace": 1, "perDay": 100},
+		"lapse": map[string]interface{}{"delays": []float64{10}, "leechAction": 0, "leechFails": 8, "minInt": 1, "mult": 0},
+	}
+	conf := map[string]interface{}{
+		"activeDecks": []int64{deckid}, "curDeck": deckid, "curModel": strconv.FormatInt(ankimodelid, 10), "nextPos": 1,
+		"newSpread": 0, "collapseTime": 1200, "timeLim": 0, "estTimes": true, "dueCounts": true,
+		"sortType": "noteFld", "sortBackwards": false, "addToCur": true,
+	}
+
+	encoded := []string{}
+	for _, value := range []interface{}{
+		conf,
+		map[string]interface{}{strconv.FormatInt(ankimodelid, 10): model},
+		map[string]interface{}{"1": deck(1, "Default"), strconv.FormatInt(deckid, 10): deck(deckid, deckname)},
+		map[string]interface{}{"1": options},
+	} {
+		data, errmarshal := json.Marshal(value)
+		if errmarshal != nil {
+			return nil, errmarshal
+		}
+		encoded = append(encoded, string(data))
+	}
+	year, month, day := created.Date()
+	crt := time.Date(year, month, day, 0, 0, 0, 0, created.Location()).Unix()
+	return []interface{}{
+		int64(1), crt, modified, modified, int64(11), int64(0), int64(0), int64(0),
+		encoded[0], encoded[1], encoded[2], encoded[3], "{}",
+	}, nil
+}
@@ -0,0 +1,120 @@
+package scraper
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Read the files of an Anki package.
+func readanki(t *testing.T, data []byte) map[string][]byte {
+	archive, errzip := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if errzip != nil {
+		t.Fatalf("zip.NewReader() = %v; want no error", errzip)
+	}
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, _ := file.Open()
+		files[file.Name], _ = io.ReadAll(reader)
+		reader.Close()
+	}
+	return files
+}
+
+func TestAnkiFields(t *testing.T) {
+	got := AnkiFields(renderentry)
+	want := []string{
+		"안녕",
+		"安寧",
+		"1.hello 2.peace",
+		"an-nyeong 안녕",
+		"",
+		`<div class="partofspeech">명사 (noun)</div><ol><li value="1"><b>peace</b><br>The state of being at peace.<br><span class="example">가족의 <u>안녕</u>을 빌다.</span></li></ol>` +
+			`<div class="partofspeech">감탄사 (interjection)</div><ol><li value="2"><b>hi (informal)</b><br>인사말.</li></ol>`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("AnkiFields() = %q; want %q", got, want)
+	}
+	escaped := AnkiFields(Entry{Title: "<b>"})
+	if escaped[0] != "&lt;b&gt;" {
+		t.Errorf("AnkiFields(<b>)[0] = %q; want %q", escaped[0], "&lt;b&gt;")
+	}
+}
+
+func TestWriteAnki(t *testing.T) {
+	audio := func(entry Entry) (string, []byte, error) {
+		return "audio/" + entry.Title + ".mp3", []byte("ID3"), nil
+	}
+	entries := []Entry{renderentry, renderentry, longentry(40)} // The repeated word is exported once.
+	var buffer bytes.Buffer
+	errwrite := WriteAnki(&buffer, AnkiDeck{Name: "Korean::TOPIK", Entries: entries, Audio: audio, Created: time.Unix(1700000000, 0)})
+	if errwrite != nil {
+		t.Fatalf("WriteAnki() = %v; want no error", errwrite)
+	}
+	files := readanki(t, buffer.Bytes())
+
+	media := map[string]string{}
+	json.Unmarshal(files["media"], &media)
+	if len(media) != 2 || media["0"] != "안녕.mp3" || media["1"] != "가다.mp3" {
+		t.Errorf("WriteAnki() media = %v; want 안녕.mp3 and 가다.mp3", media)
+	}
+	if string(files["0"]) != "ID3" {
+		t.Errorf("WriteAnki() media file 0 = %q; want %q", files["0"], "ID3")
+	}
+	collection := files["collection.anki2"]
+	if !bytes.HasPrefix(collection, []byte("SQLite format 3\x00")) {
+		t.Fatalf("WriteAnki() has no SQLite collection")
+	}
+	for _, want := range []string{ankimodelname, "Korean::TOPIK", "[sound:안녕.mp3]", " naverdict topik_elementary "} {
+		if !bytes.Contains(collection, []byte(want)) {
+			t.Errorf("WriteAnki() collection does not contain %q", want)
+		}
+	}
+}
+
+// The collection must open in SQLite itself, not only in the reader of the tests.
+func TestWriteAnkiSQLite3(t *testing.T) {
+	sqlite3, errlookup := exec.LookPath("sqlite3")
+	if errlookup != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	// Enough long notes to span interior and overflow pages.
+	entries := []Entry{renderentry, {Title: "배", ID: "1"}, {Title: "배", ID: "2"}}
+	for i := 0; i < 197; i++ {
+		entry := longentry(40)
+		entry.ID = strconv.Itoa(i)
+		entries = append(entries, entry)
+	}
+	var buffer bytes.Buffer
+	if errwrite := WriteAnki(&buffer, AnkiDeck{Entries: entries}); errwrite != nil {
+		t.Fatalf("WriteAnki() = %v; want no error", errwrite)
+	}
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	os.WriteFile(path, readanki(t, buffer.Bytes())["collection.anki2"], 0o644)
+
+	output, errsqlite := exec.Command(sqlite3, path, "PRAGMA integrity_check; SELECT count(*) FROM notes; SELECT count(*) FROM cards;").CombinedOutput()
+	if errsqlite != nil || string(output) != "ok\n200\n200\n" {
+		t.Errorf("sqlite3 integrity_check and counts = %q, %v; want ok and 200 notes and cards", output, errsqlite)
+	}
+}
+
+func TestAnkiIds(t *testing.T) {
+	if ankiguid(renderentry) != ankiguid(renderentry) || ankiguid(renderentry) == ankiguid(longentry(1)) {
+		t.Errorf("ankiguid() is not stable and unique per word")
+	}
+	// 배 is a boat, a pear and a belly, without hanja to tell them apart.
+	if ankiguid(Entry{Title: "배", ID: "1"}) == ankiguid(Entry{Title: "배", ID: "2"}) {
+		t.Errorf("ankiguid() is the same for homographs of different entries")
+	}
+	if ankideckid("a") != ankideckid("a") || ankideckid("a") == ankideckid("b") {
+		t.Errorf("ankideckid() is not stable and unique per name")
+	}
+}
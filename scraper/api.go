@@ -1,14 +1,14 @@
 package scraper
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
-	"net/http"
-	"net/url"
 	"regexp"
 )
 
+// Returned for search terms without Korean or English letters. The bot
+// replies with the welcome message, see Translate(locale, MessageWelcome).
+var ErrWelcome = errors.New(Translate(English, MessageWelcome))
+
 // Remove Illegal Characters from search term.
 func Sanitise(unsanitised string) string {
 	sanitisationpattern := "[^a-zA-Z가-힣]"
@@ -19,70 +19,22 @@ func Sanitise(unsanitised string) string {
 
 // Fetch JSON Data from URL.
 func Fetch(url string) (map[string]interface{}, error) {
-	// Create HTTP Request
-	request, errorreq := http.NewRequest(http.MethodGet, url, nil)
-	if errorreq != nil {
-		msg := fmt.Sprintf("cannot create HTTP request: %v", errorreq)
-		return nil, errors.New(msg)
-	}
-	request.Header.Add("Referer", "https://korean.dict.naver.com/koendict/") // Trick to get JSON Data.
-
-	// Send HTTP Request.
-	client := &http.Client{}
-	resp, errordo := client.Do(request)
-	if errordo != nil {
-		msg := fmt.Sprintf("cannot fetch URL %q: %v", url, errordo)
-		return nil, errors.New(msg)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		msg := fmt.Sprintf("unexpected http GET status: %s", resp.Status)
-		return nil, errors.New(msg)
-	}
-
-	// Decode JSON Data.
-	var result map[string]interface{}
-	errordecode := json.NewDecoder(resp.Body).Decode(&result)
-	if errordecode != nil {
-		msg := fmt.Sprintf("cannot decode JSON: %v", errordecode)
-		return nil, errors.New(msg)
-	}
+	return FetchWithReferer(url, KoreanEnglish.Referer)
+}
 
-	return result, nil
+// Fetch JSON Data from URL, sending the given Referer.
+func FetchWithReferer(url string, referer string) (map[string]interface{}, error) {
+	return DefaultClient.Fetch(url, referer)
 }
 
 // Format Search Term into Naver Dictionary Entry Url.
 func GetEntryUrl(searchterm string) (string, error) {
-	hostname := "https://korean.dict.naver.com"
-	// Note: Korean Search Term MUST be utf-8 encoded!
-	Url, err := url.Parse(hostname)
-	if err != nil {
-		msg := fmt.Sprintf("cannot parse URL: %v", err)
-		return "", errors.New(msg)
-	}
-
-	Url.Path += "/api3/koen/search"
-	parameters := url.Values{}
-	parameters.Add("query", searchterm)
-	parameters.Add("m", "mobile")
-	parameters.Add("range", "entrySearch")
-	Url.RawQuery = parameters.Encode()
-
-	entryurl := Url.String()
-	return entryurl, nil
+	return KoreanEnglish.GetEntryUrl(searchterm)
 }
 
 // Get Entry Information from Naver Dictionary
 func GetEntryInfo(searchterm string) (map[string]interface{}, error) {
-	entryurl, errentryurl := GetEntryUrl(searchterm)
-	if errentryurl != nil {
-		return nil, errentryurl
-	}
-	entryinfo, errentryinfo := Fetch(entryurl)
-	if errentryinfo != nil {
-		return nil, errentryinfo
-	}
-	return entryinfo, nil
+	return KoreanEnglish.GetEntryInfo(searchterm)
 }
 
 // Get the Entry ID of the Search Term from the Entry Information.
@@ -124,44 +76,26 @@ func GetEntryId(entryinfo map[string]interface{}) (string, error) {
 
 // Format Entry Id into Naver Dictionary Search Url.
 func GetSearchUrl(entryid string) (string, error) {
-	hostname := "https://korean.dict.naver.com"
-	// Note: Korean Search Term MUST be utf-8 encoded!
-	Url, err := url.Parse(hostname)
-	if err != nil {
-		msg := fmt.Sprintf("cannot parse URL: %v", err)
-		return "", errors.New(msg)
-	}
-
-	Url.Path += "/api/platform/koen/entry"
-	parameters := url.Values{}
-	parameters.Add("entryId", entryid)
-	Url.RawQuery = parameters.Encode()
-
-	searchurl := Url.String()
-	return searchurl, nil
+	return KoreanEnglish.GetSearchUrl(entryid)
 }
 
 // Get Search Information from Naver Dictionary
 func GetSearchInfo(entryid string) (map[string]interface{}, error) {
-	searchurl, errsearchurl := GetSearchUrl(entryid)
-	if errsearchurl != nil {
-		return nil, errsearchurl
-	}
-	searchinfo, errsearchinfo := Fetch(searchurl)
-	if errsearchinfo != nil {
-		return nil, errsearchinfo
-	}
-	return searchinfo, nil
+	return KoreanEnglish.GetSearchInfo(entryid)
 }
 
 // Scrape Entry Information from Naver Dictionary. (Public API)
 func GetEntryInfoRaw(searchterm string) (map[string]interface{}, error) {
+	return GetEntryInfoRawFrom(DefaultProvider, searchterm)
+}
+
+// Scrape Entry Information from a Provider. (Public API)
+func GetEntryInfoRawFrom(provider Provider, searchterm string) (map[string]interface{}, error) {
 	sanitised := Sanitise(searchterm)
 	if sanitised == "" {
-		welcomemsg := "Welcome to NaverDict Bot! Please enter a Korean word to search (e.g. 나무)."
-		return nil, errors.New(welcomemsg)
+		return nil, ErrWelcome
 	}
-	entryinfo, errentryinfo := GetEntryInfo(sanitised)
+	entryinfo, errentryinfo := provider.GetEntryInfo(sanitised)
 	if errentryinfo != nil {
 		return nil, errentryinfo
 	}
@@ -170,7 +104,12 @@ func GetEntryInfoRaw(searchterm string) (map[string]interface{}, error) {
 
 // Scrape Search Information from Naver Dictionary. (Public API)
 func GetSearchInfoRaw(searchterm string) (map[string]interface{}, error) {
-	entryinfo, errentryinfo := GetEntryInfoRaw(searchterm)
+	return GetSearchInfoRawFrom(DefaultProvider, searchterm)
+}
+
+// Scrape Search Information from a Provider. (Public API)
+func GetSearchInfoRawFrom(provider Provider, searchterm string) (map[string]interface{}, error) {
+	entryinfo, errentryinfo := GetEntryInfoRawFrom(provider, searchterm)
 	if errentryinfo != nil {
 		return nil, errentryinfo
 	}
@@ -178,7 +117,7 @@ func GetSearchInfoRaw(searchterm string) (map[string]interface{}, error) {
 	if errentryid != nil {
 		return nil, errentryid
 	}
-	searchinfo, errsearchinfo := GetSearchInfo(entryid)
+	searchinfo, errsearchinfo := provider.GetSearchInfo(entryid)
 	if errsearchinfo != nil {
 		return nil, errsearchinfo
 	}
@@ -187,23 +126,57 @@ func GetSearchInfoRaw(searchterm string) (map[string]interface{}, error) {
 
 // Scrape Naver Dictionary from a Search Term. (Public API)
 func Get(searchterm string) (DictInfo, error) {
-	searchinfo, errsearchinfo := GetSearchInfoRaw(searchterm)
+	return GetFrom(DefaultProvider, searchterm)
+}
+
+// Scrape a Provider from a Search Term. (Public API)
+func GetFrom(provider Provider, searchterm string) (DictInfo, error) {
+	entry, errentry := GetEntryFrom(provider, searchterm)
+	if errentry != nil {
+		return DictInfo{}, errentry
+	}
+	return entry.DictInfo(), nil
+}
+
+// Scrape Naver Dictionary from a Search Term into an Entry. (Public API)
+func GetEntry(searchterm string) (Entry, error) {
+	return GetEntryFrom(DefaultProvider, searchterm)
+}
+
+// Scrape a Provider from a Search Term into an Entry. (Public API)
+func GetEntryFrom(provider Provider, searchterm string) (Entry, error) {
+	searchinfo, errsearchinfo := GetSearchInfoRawFrom(provider, searchterm)
 	if errsearchinfo != nil {
-		return DictInfo{}, errsearchinfo
+		return Entry{}, errsearchinfo
 	}
-	dictinfo, errscrape := Scrape(searchinfo)
+	entry, errscrape := ScrapeEntry(searchinfo)
 	if errscrape != nil {
-		return DictInfo{}, errscrape
+		return Entry{}, errscrape
 	}
-	return dictinfo, nil
+	AddHeadwords([]string{entry.Title}) // Remember for offline suggestions.
+	return entry, nil
 }
 
 // Scrape Naver Dictionary from a Search Term. (Public API)
 func GetMessage(searchterm string) (string, error) {
-	dictinfo, err := Get(searchterm)
+	return GetMessageFrom(DefaultProvider, searchterm)
+}
+
+// Scrape a Provider from a Search Term into a Message. (Public API)
+func GetMessageFrom(provider Provider, searchterm string) (string, error) {
+	return GetMessageFromAs(provider, DefaultRenderer, searchterm)
+}
+
+// Scrape Naver Dictionary from a Search Term into a Message rendered by a Renderer. (Public API)
+func GetMessageAs(renderer Renderer, searchterm string) (string, error) {
+	return GetMessageFromAs(DefaultProvider, renderer, searchterm)
+}
+
+// Scrape a Provider from a Search Term into a Message rendered by a Renderer. (Public API)
+func GetMessageFromAs(provider Provider, renderer Renderer, searchterm string) (string, error) {
+	entry, err := GetEntryFrom(provider, searchterm)
 	if err != nil {
 		return "", err
 	}
-	message := Buildmessage(dictinfo)
-	return message, nil
+	return RenderEntry(renderer, entry)
 }
@@ -0,0 +1,483 @@
+package scraper
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Width of word cards, in pixels. The height depends on the word.
+const CardWidth = 800
+
+// Number of glosses on a word card.
+const cardglosses = 3
+
+// Colours of word cards.
+var (
+	cardbackground = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	cardtext       = color.RGBA{0x21, 0x21, 0x21, 0xFF}
+	cardmuted      = color.RGBA{0x75, 0x75, 0x75, 0xFF}
+	cardaccent     = color.RGBA{0xC6, 0x28, 0x28, 0xFF} // Emphasis in examples.
+	cardrule       = color.RGBA{0xE0, 0xE0, 0xE0, 0xFF}
+	cardstar       = color.RGBA{0xF9, 0xA8, 0x25, 0xFF}
+	cardbadges     = map[TopikLevel]color.RGBA{
+		TopikElementary:   {0x2E, 0x7D, 0x32, 0xFF},
+		TopikIntermediate: {0x15, 0x65, 0xC0, 0xFF},
+	}
+)
+
+// NanumBarunGothic, for Hangul, under the SIL Open Font License. It has no
+// hanja, nor a bold weight.
+//
+//go:embed fonts/NanumBarunGothic.ttf
+var nanumbarungothic []byte
+
+// The hanja of KS X 1001 from Noto Sans CJK KR Bold, under the SIL Open
+// Font License. See fonts/mkhanja.
+//
+//go:embed fonts/NotoSansCJKkr-Hanja.ttf
+var notosanskrhanja []byte
+
+// cardfonts stores the fonts of word cards, tried in order for each
+// character. Bold Latin text is drawn with Go Bold, hanja with Noto Sans
+// CJK KR and the rest with NanumBarunGothic, falling back to the Go fonts.
+var cardfonts = struct {
+	sync.RWMutex
+	once    sync.Once
+	regular []*sfnt.Font
+	bold    []*sfnt.Font
+}{}
+
+// Parse the embedded card fonts on first use, so that programs that only
+// look words up do not spend time and memory on them.
+func loadcardfonts() {
+	cardfonts.once.Do(func() {
+		nanum, hanja := mustparsefont(nanumbarungothic), mustparsefont(notosanskrhanja)
+		cardfonts.Lock()
+		cardfonts.regular = append(cardfonts.regular, nanum, hanja, mustparsefont(goregular.TTF))
+		cardfonts.bold = append(cardfonts.bold, mustparsefont(gobold.TTF), nanum, hanja)
+		cardfonts.Unlock()
+	})
+}
+
+// A carddrawer with the card fonts loaded so far.
+func newcarddrawer() *carddrawer {
+	loadcardfonts()
+	cardfonts.RLock()
+	defer cardfonts.RUnlock()
+	return &carddrawer{regular: cardfonts.regular, bold: cardfonts.bold, faces: map[cardfacekey]font.Face{}}
+}
+
+func mustparsefont(data []byte) *sfnt.Font {
+	parsed, errparse := opentype.Parse(data)
+	if errparse != nil {
+		panic(errparse)
+	}
+	return parsed
+}
+
+// Load a TrueType or OpenType font to draw word cards with, e.g. for a
+// different style or for hanja outside KS X 1001. Fonts loaded later are
+// tried first. The first font of a collection (.ttc) is used.
+func LoadCardFont(path string) error {
+	data, errread := os.ReadFile(path)
+	if errread != nil {
+		return errread
+	}
+	parsed, errparse := opentype.Parse(data)
+	if errparse != nil {
+		collection, errcollection := opentype.ParseCollection(data)
+		if errcollection != nil {
+			msg := fmt.Sprintf("cannot parse font %s: %v", path, errparse)
+			return errors.New(msg)
+		}
+		parsed, errparse = collection.Font(0)
+		if errparse != nil {
+			return errparse
+		}
+	}
+	loadcardfonts()
+	cardfonts.Lock()
+	cardfonts.regular = append([]*sfnt.Font{parsed}, cardfonts.regular...)
+	cardfonts.bold = append([]*sfnt.Font{parsed}, cardfonts.bold...)
+	cardfonts.Unlock()
+	cardcache.clear()
+	return nil
+}
+
+// cardcache stores the latest cards by locale and entry id.
+var cardcache = &pngcache{limit: 256, cards: map[string][]byte{}}
+
+// pngcache is a cache that drops its oldest card when full.
+type pngcache struct {
+	sync.Mutex
+	limit int
+	order []string
+	cards map[string][]byte
+}
+
+func (cache *pngcache) get(key string) ([]byte, bool) {
+	cache.Lock()
+	defer cache.Unlock()
+	card, found := cache.cards[key]
+	return card, found
+}
+
+func (cache *pngcache) put(key string, card []byte) {
+	cache.Lock()
+	defer cache.Unlock()
+	if _, found := cache.cards[key]; found {
+		return
+	}
+	if len(cache.order) >= cache.limit {
+		delete(cache.cards, cache.order[0])
+		cache.order = cache.order[1:]
+	}
+	cache.order = append(cache.order, key)
+	cache.cards[key] = card
+}
+
+func (cache *pngcache) clear() {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.order = nil
+	cache.cards = map[string][]byte{}
+}
+
+// Draw an Entry as a PNG word card.
+func RenderCard(entry Entry) ([]byte, error) {
+	return RenderCardIn(DefaultLocale, entry)
+}
+
+// Draw an Entry as a PNG word card with labels in a locale. Cards of
+// Entries with an ID are cached.
+func RenderCardIn(locale string, entry Entry) ([]byte, error) {
+	key := locale + "\x1f" + entry.ID
+	if entry.ID != "" {
+		if card, found := cardcache.get(key); found {
+			return card, nil
+		}
+	}
+
+	drawer := newcarddrawer()
+	defer drawer.close()
+
+	var card bytes.Buffer
+	errencode := png.Encode(&card, drawer.draw(locale, entry))
+	if errencode != nil {
+		return nil, errencode
+	}
+	if entry.ID != "" {
+		cardcache.put(key, card.Bytes())
+	}
+	return card.Bytes(), nil
+}
+
+// cardrun is text in one style.
+type cardrun struct {
+	text   string
+	size   float64
+	colour color.Color
+	bold   bool
+}
+
+// cardline is runs drawn on one line, below the previous line.
+type cardline struct {
+	runs   []cardrun
+	height float64 // Distance from the previous baseline.
+	rule   bool    // A horizontal rule instead of text.
+}
+
+type cardfacekey struct {
+	font *sfnt.Font
+	size float64
+}
+
+// carddrawer lays out and draws a card. Faces are not safe for concurrent
+// use, so each card has its own.
+type carddrawer struct {
+	regular []*sfnt.Font
+	bold    []*sfnt.Font
+	faces   map[cardfacekey]font.Face
+	buffer  sfnt.Buffer
+}
+
+func (drawer *carddrawer) close() {
+	for _, face := range drawer.faces {
+		face.Close()
+	}
+}
+
+func (drawer *carddrawer) face(parsed *sfnt.Font, size float64) font.Face {
+	key := cardfacekey{parsed, size}
+	if face, found := drawer.faces[key]; found {
+		return face
+	}
+	face, _ := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	drawer.faces[key] = face
+	return face
+}
+
+// Split a run into segments drawn with one face each, choosing for each
+// character the first font that has it.
+func (drawer *carddrawer) segments(run cardrun) ([]string, []font.Face) {
+	fonts := drawer.regular
+	if run.bold {
+		fonts = drawer.bold
+	}
+	texts, faces := []string{}, []font.Face{}
+	var current *sfnt.Font
+	for _, char := range run.text {
+		chosen := fonts[len(fonts)-1]
+		for _, candidate := range fonts {
+			index, errindex := candidate.GlyphIndex(&drawer.buffer, char)
+			if errindex == nil && index != 0 {
+				chosen = candidate
+				break
+			}
+		}
+		if chosen != current || len(texts) == 0 {
+			texts = append(texts, "")
+			faces = append(faces, drawer.face(chosen, run.size))
+			current = chosen
+		}
+		texts[len(texts)-1] += string(char)
+	}
+	return texts, faces
+}
+
+// Whether a font has every character of a text, e.g. of the hanja, which
+// are left out rather than drawn as boxes.
+func (drawer *carddrawer) covers(text string) bool {
+	for _, char := range text {
+		found := false
+		for _, candidate := range drawer.regular {
+			index, errindex := candidate.GlyphIndex(&drawer.buffer, char)
+			if errindex == nil && index != 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Width of a run, in pixels.
+func (drawer *carddrawer) measure(run cardrun) float64 {
+	width := fixed.Int26_6(0)
+	texts, faces := drawer.segments(run)
+	for i, text := range texts {
+		width += font.MeasureString(faces[i], text)
+	}
+	return float64(width) / 64
+}
+
+// Draw a run with its left end of the baseline at x, y. Returns its width.
+func (drawer *carddrawer) drawrun(canvas draw.Image, run cardrun, x float64, y float64) float64 {
+	dot := fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}
+	texts, faces := drawer.segments(run)
+	for i, text := range texts {
+		fontdrawer := font.Drawer{Dst: canvas, Src: image.NewUniform(run.colour), Face: faces[i], Dot: dot}
+		fontdrawer.DrawString(text)
+		dot = fontdrawer.Dot
+	}
+	return float64(dot.X)/64 - x
+}
+
+// Wrap runs into lines of at most width pixels, breaking between words.
+func (drawer *carddrawer) wrap(runs []cardrun, width float64, height float64) []cardline {
+	lines := []cardline{{height: height}}
+	linewidth := 0.0
+	for _, run := range runs {
+		words := strings.SplitAfter(run.text, " ")
+		for _, word := range words {
+			if word == "" {
+				continue
+			}
+			piece := run
+			piece.text = word
+			wordwidth := drawer.measure(piece)
+			if linewidth > 0 && linewidth+drawer.measure(cardrun{strings.TrimRight(word, " "), run.size, run.colour, run.bold}) > width {
+				lines = append(lines, cardline{height: height})
+				linewidth = 0
+			}
+			last := &lines[len(lines)-1]
+			last.runs = append(last.runs, piece)
+			linewidth += wordwidth
+		}
+	}
+	return lines
+}
+
+// Lay out and draw the card of an Entry.
+func (drawer *carddrawer) draw(locale string, entry Entry) *image.RGBA {
+	const padding = 40.0
+	textwidth := CardWidth - 2*padding
+
+	// The headword and hanja, leaving room for the badge and stars.
+	lines := []cardline{{height: 72, runs: []cardrun{{entry.Title, 64, cardtext, true}}}}
+	if entry.Hanja != "" && drawer.covers(entry.Hanja) {
+		lines[0].runs = append(lines[0].runs, cardrun{"  " + entry.Hanja, 36, cardmuted, false})
+	}
+	reading := Buildsentence("", []string{entry.Romanisation, entry.Pronunciation})
+	if entry.Romanisation != "" && entry.Pronunciation != "" {
+		reading = "[" + entry.Romanisation + "]  " + entry.Pronunciation
+	}
+	if reading != "" {
+		lines = append(lines, cardline{height: 44, runs: []cardrun{{reading, 26, cardmuted, false}}})
+	}
+
+	glosses := entry.Glosses
+	if len(glosses) > cardglosses {
+		glosses = glosses[:cardglosses]
+	}
+	var example *Markup
+	for _, sense := range entry.Senses {
+		if len(sense.Examples) > 0 {
+			example = &sense.Examples[0]
+			break
+		}
+	}
+	if len(glosses) > 0 || example != nil {
+		lines = append(lines, cardline{height: 28, rule: true})
+	}
+	for i, gloss := range glosses {
+		height := 40.0
+		if i == 0 {
+			height = 48
+		}
+		wrapped := drawer.wrap([]cardrun{{fmt.Sprintf("%d. %s", i+1, gloss), 28, cardtext, false}}, textwidth, 38)
+		wrapped[0].height = height
+		lines = append(lines, wrapped...)
+	}
+	if example != nil {
+		runs := []cardrun{}
+		offset := 0
+		for _, span := range example.Spans {
+			if span.Start < offset || span.End > len(example.Text) || span.Start >= span.End {
+				continue
+			}
+			runs = append(runs, cardrun{example.Text[offset:span.Start], 24, cardmuted, false})
+			runs = append(runs, cardrun{example.Text[span.Start:span.End], 24, cardaccent, true})
+			offset = span.End
+		}
+		runs = append(runs, cardrun{example.Text[offset:], 24, cardmuted, false})
+		wrapped := drawer.wrap(runs, textwidth, 34)
+		wrapped[0].height = 52
+		lines = append(lines, wrapped...)
+	}
+
+	height := padding
+	for _, line := range lines {
+		height += line.height
+	}
+	height += padding
+	canvas := image.NewRGBA(image.Rect(0, 0, CardWidth, int(math.Ceil(height))))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(cardbackground), image.Point{}, draw.Src)
+
+	y := padding
+	for _, line := range lines {
+		y += line.height
+		if line.rule {
+			fillrect(canvas, padding, y-14, CardWidth-padding, y-12, cardrule)
+			continue
+		}
+		x := padding
+		for _, run := range line.runs {
+			x += drawer.drawrun(canvas, run, x, y)
+		}
+	}
+
+	// The TOPIK badge and importance stars in the top right corner.
+	right := CardWidth - padding
+	if badge, found := cardbadges[entry.TopikLevel]; found {
+		label := cardrun{strings.Trim(entry.TopikLevel.LabelIn(locale), "()"), 20, cardbackground, true}
+		width := drawer.measure(label) + 24
+		fillroundrect(canvas, right-width, padding, right, padding+34, 17, badge)
+		drawer.drawrun(canvas, label, right-width+12, padding+24)
+	}
+	for i := 0; i < 3; i++ {
+		colour := cardrule
+		if i < entry.Importance {
+			colour = cardstar
+		}
+		fillstar(canvas, right-12-float64(2-i)*30, padding+62, 13, colour)
+	}
+	return canvas
+}
+
+// Fill a path drawn by a function on a rasteriser.
+func fillpath(canvas draw.Image, colour color.Color, path func(rasteriser *vector.Rasterizer)) {
+	bounds := canvas.Bounds()
+	rasteriser := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	path(rasteriser)
+	rasteriser.Draw(canvas, bounds, image.NewUniform(colour), image.Point{})
+}
+
+func fillrect(canvas draw.Image, x0 float64, y0 float64, x1 float64, y1 float64, colour color.Color) {
+	fillpath(canvas, colour, func(rasteriser *vector.Rasterizer) {
+		rasteriser.MoveTo(float32(x0), float32(y0))
+		rasteriser.LineTo(float32(x1), float32(y0))
+		rasteriser.LineTo(float32(x1), float32(y1))
+		rasteriser.LineTo(float32(x0), float32(y1))
+		rasteriser.ClosePath()
+	})
+}
+
+func fillroundrect(canvas draw.Image, x0 float64, y0 float64, x1 float64, y1 float64, radius float64, colour color.Color) {
+	fillpath(canvas, colour, func(rasteriser *vector.Rasterizer) {
+		corners := [][3]float64{{x1 - radius, y0 + radius, -90}, {x1 - radius, y1 - radius, 0}, {x0 + radius, y1 - radius, 90}, {x0 + radius, y0 + radius, 180}}
+		for i, corner := range corners {
+			for step := 0; step <= 8; step++ {
+				angle := (corner[2] + float64(step)*90/8) * math.Pi / 180
+				x, y := float32(corner[0]+radius*math.Cos(angle)), float32(corner[1]+radius*math.Sin(angle))
+				if i == 0 && step == 0 {
+					rasteriser.MoveTo(x, y)
+				} else {
+					rasteriser.LineTo(x, y)
+				}
+			}
+		}
+		rasteriser.ClosePath()
+	})
+}
+
+// Fill a five-pointed star centred on x, y.
+func fillstar(canvas draw.Image, x float64, y float64, radius float64, colour color.Color) {
+	fillpath(canvas, colour, func(rasteriser *vector.Rasterizer) {
+		for point := 0; point < 10; point++ {
+			length := radius
+			if point%2 == 1 {
+				length = radius * 0.45
+			}
+			angle := (float64(point)*36 - 90) * math.Pi / 180
+			px, py := float32(x+length*math.Cos(angle)), float32(y+length*math.Sin(angle))
+			if point == 0 {
+				rasteriser.MoveTo(px, py)
+			} else {
+				rasteriser.LineTo(px, py)
+			}
+		}
+		rasteriser.ClosePath()
+	})
+}
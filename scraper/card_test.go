@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestRenderCard(t *testing.T) {
+	card, errrender := RenderCard(renderentry)
+	if errrender != nil {
+		t.Fatalf("RenderCard() error = %v", errrender)
+	}
+	decoded, errdecode := png.Decode(bytes.NewReader(card))
+	if errdecode != nil {
+		t.Fatalf("RenderCard() is not a PNG: %v", errdecode)
+	}
+	if width := decoded.Bounds().Dx(); width != CardWidth {
+		t.Errorf("RenderCard() width = %d; want %d", width, CardWidth)
+	}
+
+	// Longer glosses wrap onto more lines, so the card is taller.
+	long := renderentry
+	long.Glosses = []string{"hello", "a gloss long enough to need more than one line on a word card, twice over or more"}
+	longcard, _ := RenderCard(long)
+	decodedlong, _ := png.Decode(bytes.NewReader(longcard))
+	if decodedlong.Bounds().Dy() <= decoded.Bounds().Dy() {
+		t.Errorf("RenderCard(long).Bounds().Dy() = %d; want more than %d", decodedlong.Bounds().Dy(), decoded.Bounds().Dy())
+	}
+}
+
+func TestRenderCardCache(t *testing.T) {
+	cardcache.clear()
+	entry := renderentry
+	entry.ID = "cardtest"
+	first, _ := RenderCardIn(Korean, entry)
+	if _, found := cardcache.get(Korean + "\x1f" + entry.ID); !found {
+		t.Errorf("RenderCardIn(%q, %q) is not cached", Korean, entry.ID)
+	}
+	entry.Glosses = []string{"changed"}
+	second, _ := RenderCardIn(Korean, entry)
+	if !bytes.Equal(first, second) {
+		t.Errorf("RenderCardIn(%q, %q) twice = different cards; want the cached card", Korean, entry.ID)
+	}
+
+	entry.ID = ""
+	RenderCardIn(Korean, entry)
+	if _, found := cardcache.get(Korean + "\x1f"); found {
+		t.Errorf("RenderCardIn() cached an Entry without an ID")
+	}
+}
+
+func TestLoadCardFont(t *testing.T) {
+	if errload := LoadCardFont("testdata/missing.ttf"); errload == nil {
+		t.Errorf("LoadCardFont(%q) = nil; want error", "testdata/missing.ttf")
+	}
+	if errload := LoadCardFont("render_test.go"); errload == nil {
+		t.Errorf("LoadCardFont(%q) = nil; want error", "render_test.go")
+	}
+}
+
+func TestCardCovers(t *testing.T) {
+	drawer := newcarddrawer()
+	defer drawer.close()
+	if !drawer.covers("안녕 hello") {
+		t.Errorf("covers(%q) = false; want true", "안녕 hello")
+	}
+	if !drawer.covers("安寧 學校 愛") {
+		t.Errorf("covers(%q) = false; want true with the embedded hanja", "安寧 學校 愛")
+	}
+	if drawer.covers("𠀀") {
+		t.Errorf("covers(%q) = true; want false outside KS X 1001", "𠀀")
+	}
+}
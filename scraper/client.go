@@ -0,0 +1,161 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Client sends requests to Naver Dictionary.
+type Client struct {
+	HTTPClient *http.Client
+	BaseUrl    *url.URL     // Replaces the scheme and host of every request when set, e.g. for testing.
+	Limiter    *RateLimiter // Throttles requests when set. May be shared between clients.
+	Lane       Lane         // Priority of this client's requests in the Limiter.
+	Header     http.Header  // Sent with every request, e.g. User-Agent. The Referer is set per provider.
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client) error
+
+// Create a Client. Without options it behaves like a plain http.Client.
+func NewClient(options ...ClientOption) (*Client, error) {
+	client := &Client{HTTPClient: &http.Client{}}
+	erroptions := client.Configure(options...)
+	if erroptions != nil {
+		return nil, erroptions
+	}
+	return client, nil
+}
+
+// Apply options to an existing Client, e.g. the DefaultClient.
+func (client *Client) Configure(options ...ClientOption) error {
+	for _, option := range options {
+		erroption := option(client)
+		if erroption != nil {
+			return erroption
+		}
+	}
+	return nil
+}
+
+// Send requests through the given transport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(client *Client) error {
+		client.HTTPClient.Transport = transport
+		return nil
+	}
+}
+
+// Send requests to baseurl (e.g. http://127.0.0.1:8080) instead of Naver.
+func WithBaseUrl(baseurl string) ClientOption {
+	return func(client *Client) error {
+		parsed, errparse := url.Parse(baseurl)
+		if errparse != nil || parsed.Scheme == "" || parsed.Host == "" {
+			msg := fmt.Sprintf("invalid base URL %q", baseurl)
+			return errors.New(msg)
+		}
+		client.BaseUrl = parsed
+		return nil
+	}
+}
+
+// Send requests through a pool of proxies.
+func WithProxyPool(pool *ProxyPool) ClientOption {
+	return WithTransport(pool)
+}
+
+// Send a header with every request, e.g. WithHeader("Accept-Language", "ko-KR,ko;q=0.9").
+func WithHeader(name string, value string) ClientOption {
+	return func(client *Client) error {
+		if client.Header == nil {
+			client.Header = http.Header{}
+		}
+		client.Header.Set(name, value)
+		return nil
+	}
+}
+
+// Send a User-Agent with every request, instead of Go's default.
+func WithUserAgent(useragent string) ClientOption {
+	return WithHeader("User-Agent", useragent)
+}
+
+// Send an Accept-Language with every request.
+func WithAcceptLanguage(language string) ClientOption {
+	return WithHeader("Accept-Language", language)
+}
+
+// Throttle requests with limiter, in the given lane.
+func WithRateLimiter(limiter *RateLimiter, lane Lane) ClientOption {
+	return func(client *Client) error {
+		client.Limiter = limiter
+		client.Lane = lane
+		return nil
+	}
+}
+
+// Copy the client, sending requests in another lane of the same RateLimiter.
+func (client *Client) WithLane(lane Lane) *Client {
+	copied := *client
+	copied.Lane = lane
+	return &copied
+}
+
+// Rate limiter of the DefaultClient: 10 requests per second per host, in bursts of up to 20.
+var DefaultRateLimiter = NewRateLimiter(10, 20)
+
+// Client used by Fetch and the Naver providers.
+var DefaultClient = &Client{HTTPClient: &http.Client{}, Limiter: DefaultRateLimiter}
+
+// Fetch JSON Data from URL, sending the given Referer.
+func (client *Client) Fetch(url string, referer string) (map[string]interface{}, error) {
+	// Create HTTP Request
+	request, errorreq := http.NewRequest(http.MethodGet, url, nil)
+	if errorreq != nil {
+		msg := fmt.Sprintf("cannot create HTTP request: %v", errorreq)
+		return nil, errors.New(msg)
+	}
+	for name, values := range client.Header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Referer", referer) // Trick to get JSON Data.
+	if client.BaseUrl != nil {
+		request.URL.Scheme = client.BaseUrl.Scheme
+		request.URL.Host = client.BaseUrl.Host
+		request.Host = ""
+	}
+
+	// Wait for the Rate Limiter.
+	if client.Limiter != nil {
+		errwait := client.Limiter.Wait(request.Context(), request.URL.Host, client.Lane)
+		if errwait != nil {
+			msg := fmt.Sprintf("cannot fetch URL %q: %v", url, errwait)
+			return nil, errors.New(msg)
+		}
+	}
+
+	// Send HTTP Request.
+	resp, errordo := client.HTTPClient.Do(request)
+	if errordo != nil {
+		msg := fmt.Sprintf("cannot fetch URL %q: %v", url, errordo)
+		return nil, errors.New(msg)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg := fmt.Sprintf("unexpected http GET status: %s", resp.Status)
+		return nil, errors.New(msg)
+	}
+
+	// Decode JSON Data.
+	var result map[string]interface{}
+	errordecode := json.NewDecoder(resp.Body).Decode(&result)
+	if errordecode != nil {
+		msg := fmt.Sprintf("cannot decode JSON: %v", errordecode)
+		return nil, errors.New(msg)
+	}
+
+	return result, nil
+}
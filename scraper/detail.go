@@ -0,0 +1,83 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Detail is how much of an Entry a message shows.
+type Detail string
+
+// Details of a message.
+const (
+	DetailCompact  Detail = "compact"  // The title, hanja, pronunciation and top glosses, e.g. for group chats.
+	DetailStandard Detail = "standard" // Every sense with its first example, as Buildmessage.
+	DetailFull     Detail = "full"     // Every sense with every example, and related words.
+)
+
+// Detail of renderers when none is chosen.
+var DefaultDetail = DetailStandard
+
+// Number of glosses in a compact message.
+const compactglosses = 3
+
+// Parse a Detail by name. An empty name returns the DefaultDetail.
+func ParseDetail(detail string) (Detail, error) {
+	switch parsed := Detail(strings.ToLower(detail)); parsed {
+	case "":
+		return DefaultDetail, nil
+	case DetailCompact, DetailStandard, DetailFull:
+		return parsed, nil
+	}
+	msg := fmt.Sprintf("unknown detail %q, want compact, standard or full", detail)
+	return "", errors.New(msg)
+}
+
+// Trim an Entry to what a Detail shows. An empty Detail keeps everything.
+func (detail Detail) Apply(entry Entry) Entry {
+	switch detail {
+	case DetailCompact:
+		trimmed := Entry{
+			Title:         entry.Title,
+			Hanja:         entry.Hanja,
+			Romanisation:  entry.Romanisation,
+			Pronunciation: entry.Pronunciation,
+			Glosses:       entry.Glosses,
+			Senses:        []Sense{},
+			Groups:        []SenseGroup{},
+		}
+		if len(trimmed.Glosses) > compactglosses {
+			trimmed.Glosses = trimmed.Glosses[:compactglosses]
+		}
+		return trimmed
+	case DetailStandard:
+		senses := make([]Sense, len(entry.Senses))
+		for i, sense := range entry.Senses {
+			if len(sense.Examples) > 1 {
+				sense.Examples = sense.Examples[:1]
+			}
+			senses[i] = sense
+		}
+		entry.Senses = senses
+		entry.Related = nil
+	}
+	return entry
+}
+
+// DetailedRenderer is a Renderer that can render at a Detail and in a
+// locale, e.g. to show every example of a sense.
+type DetailedRenderer interface {
+	LocalisedRenderer
+	RenderDetail(locale string, detail Detail, entry Entry) string
+}
+
+// Render with a Renderer at a Detail. Renderers that are not
+// DetailedRenderers, e.g. templates, render the trimmed Entry.
+func WithDetail(renderer Renderer, detail Detail) Renderer {
+	if configured, found := renderer.(configured); found {
+		configured.detail = detail
+		return configured
+	}
+	return configured{renderer, DefaultLocale, detail}
+}
@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+// An entry with many glosses and examples.
+var detailentry = Entry{
+	Title:         "가다",
+	Hanja:         "",
+	Romanisation:  "ga-da",
+	Pronunciation: "가다",
+	TopikLevel:    TopikElementary,
+	Importance:    3,
+	Glosses:       []string{"go", "leave", "attend", "head"},
+	Senses: []Sense{
+		{Number: 1, PartOfSpeech: "동사", PartOfSpeechEnglish: "verb", Gloss: "go", Definition: "To move.",
+			Examples: []Markup{{Text: "학교에 가다."}, {Text: "집에 가다."}}},
+	},
+}
+
+func TestParseDetail(t *testing.T) {
+	tests := map[string]Detail{"": DefaultDetail, "Compact": DetailCompact, "full": DetailFull}
+	for name, want := range tests {
+		got, errparse := ParseDetail(name)
+		if got != want || errparse != nil {
+			t.Errorf("ParseDetail(%q) = %q, %v; want %q", name, got, errparse, want)
+		}
+	}
+	_, errparse := ParseDetail("verbose")
+	if errparse == nil {
+		t.Errorf("ParseDetail(%q) = nil; want error", "verbose")
+	}
+}
+
+func TestDetailPlainText(t *testing.T) {
+	compact := WithDetail(PlainText, DetailCompact).Render(detailentry)
+	want := "가다\n1.go 2.leave 3.attend\n----------\nPronunciation:\nroma [ga-da] [가다]"
+	if compact != want {
+		t.Errorf("WithDetail(plain, compact).Render() = %q; want %q", compact, want)
+	}
+	standard := WithDetail(PlainText, DetailStandard).Render(detailentry)
+	if standard != PlainText.Render(detailentry) || strings.Contains(standard, "집에") {
+		t.Errorf("WithDetail(plain, standard).Render() = %q; want the first example only", standard)
+	}
+	full := WithDetail(PlainText, DetailFull).Render(detailentry)
+	if !strings.HasSuffix(full, "|| 학교에 가다.\n|| 집에 가다.") {
+		t.Errorf("WithDetail(plain, full).Render() = %q; want every example", full)
+	}
+}
+
+func TestDetailFormats(t *testing.T) {
+	compact := WithDetail(TelegramHTML, DetailCompact).Render(detailentry)
+	want := "<b>가다</b>\n1.go 2.leave 3.attend\n----------\n<i>Pronunciation:</i> ga-da 가다"
+	if compact != want {
+		t.Errorf("WithDetail(html, compact).Render() = %q; want %q", compact, want)
+	}
+	full := Localise(WithDetail(MarkdownV2, DetailFull), Korean).Render(detailentry)
+	if !strings.Contains(full, "_발음:_") || !strings.Contains(full, "집에 가다\\.") {
+		t.Errorf("Localise(WithDetail(markdownv2, full)).Render() = %q; want Korean labels and every example", full)
+	}
+	if MarkdownV2.Render(detailentry) != WithDetail(MarkdownV2, DetailStandard).Render(detailentry) {
+		t.Errorf("MarkdownV2.Render() = %q; want the standard detail", MarkdownV2.Render(detailentry))
+	}
+}
+
+func TestDetailRelated(t *testing.T) {
+	entry := detailentry
+	entry.Related = []string{"오다", "떠나다"}
+	for _, detail := range []Detail{DetailCompact, DetailStandard} {
+		if got := WithDetail(PlainText, detail).Render(entry); strings.Contains(got, "떠나다") {
+			t.Errorf("WithDetail(plain, %s).Render() = %q; want no related words", detail, got)
+		}
+	}
+	full := WithDetail(PlainText, DetailFull).Render(entry)
+	if !strings.HasSuffix(full, "|| 집에 가다.\n----------\nRelated:\n오다, 떠나다") {
+		t.Errorf("WithDetail(plain, full).Render() = %q; want related words", full)
+	}
+	full = Localise(WithDetail(TelegramHTML, DetailFull), Korean).Render(entry)
+	if !strings.HasSuffix(full, "----------\n<i>관련어:</i> 오다, 떠나다") {
+		t.Errorf("Localise(WithDetail(html, full), %q).Render() = %q; want related words", Korean, full)
+	}
+}
+
+func TestDetailTemplate(t *testing.T) {
+	template, _ := NewTemplateRenderer("count", "{{len .Glosses}} {{len .Senses}}", nil)
+	got := WithDetail(template, DetailCompact).Render(detailentry)
+	if got != "3 0" {
+		t.Errorf("WithDetail(template, compact).Render() = %q; want %q", got, "3 0")
+	}
+}
@@ -0,0 +1,343 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Entry is the structured dictionary information of a word. DictInfo is
+// the same information pre-formatted for messages, see Entry.DictInfo.
+type Entry struct {
+	ID            string       `json:"id,omitempty"` // Naver entryId, if known.
+	Title         string       `json:"title"`
+	Hanja         string       `json:"hanja"`
+	Romanisation  string       `json:"romanisation"`
+	Pronunciation string       `json:"pronunciation"` // In Hangul.
+	TopikLevel    TopikLevel   `json:"topik_level"`
+	Importance    int          `json:"importance"` // Stars from 0 to 3.
+	Glosses       []string     `json:"glosses"`    // Short English translations.
+	Senses        []Sense      `json:"senses"`
+	Groups        []SenseGroup `json:"groups"`            // Senses grouped by part of speech.
+	Related       []string     `json:"related,omitempty"` // Related words, e.g. synonyms. Only in full messages.
+}
+
+// Sense is one meaning of an Entry.
+type Sense struct {
+	Number              int      `json:"number"`         // From 1.
+	PartOfSpeech        string   `json:"part_of_speech"` // In Korean, e.g. 명사.
+	PartOfSpeechEnglish string   `json:"part_of_speech_en"`
+	Gloss               string   `json:"gloss"`             // Short English translation.
+	Definition          string   `json:"definition"`        // English definition.
+	KoreanDefinition    string   `json:"korean_definition"` // Korean definition.
+	Examples            []Markup `json:"examples"`
+}
+
+// SenseGroup is the Senses of an Entry with the same part of speech.
+type SenseGroup struct {
+	PartOfSpeech        string `json:"part_of_speech"`
+	PartOfSpeechEnglish string `json:"part_of_speech_en"`
+	Senses              []int  `json:"senses"` // Numbers of the Senses.
+}
+
+// English names of Korean parts of speech, for senses without one upstream.
+var partofspeechnames = map[string]string{
+	"명사":     "noun",
+	"의존 명사":  "bound noun",
+	"대명사":    "pronoun",
+	"수사":     "numeral",
+	"동사":     "verb",
+	"보조 동사":  "auxiliary verb",
+	"형용사":    "adjective",
+	"보조 형용사": "auxiliary adjective",
+	"관형사":    "determiner",
+	"부사":     "adverb",
+	"감탄사":    "interjection",
+	"조사":     "particle",
+	"어미":     "ending",
+	"접사":     "affix",
+	"접두사":    "prefix",
+	"접미사":    "suffix",
+	"의존명사":   "bound noun",
+	"보조동사":   "auxiliary verb",
+	"보조형용사":  "auxiliary adjective",
+}
+
+// Scrape English Glosses
+func GetGlosses(searchinfo map[string]interface{}) ([]string, error) {
+	// Equivalent to searchInfo.entry.primary_mean.split("|||")
+	entry, errorentry := searchinfo["entry"].(map[string]interface{})
+	if !errorentry {
+		return nil, errors.New("Cannot find entry in searchinfo")
+	}
+	primarymean, errorprimarymean := entry["primary_mean"].(string)
+	if !errorprimarymean {
+		return nil, errors.New("Cannot find primaryMean in entry")
+	}
+	glosses := []string{}
+	for _, gloss := range strings.Split(StripMarkup(primarymean), `|||`) {
+		gloss = strings.TrimSpace(gloss)
+		if gloss != "" {
+			glosses = append(glosses, gloss)
+		}
+	}
+	return glosses, nil
+}
+
+// Scrape Related Words
+func GetRelated(searchinfo map[string]interface{}) ([]string, error) {
+	// Equivalent to searchInfo.entry.related_words[].entry_name
+	entry, errorentry := searchinfo["entry"].(map[string]interface{})
+	if !errorentry {
+		return nil, errors.New("Cannot find entry in searchinfo")
+	}
+	relatedwords, errorrelatedwords := entry["related_words"].([]interface{})
+	if !errorrelatedwords {
+		return nil, errors.New("Cannot find relatedWords in entry")
+	}
+	related := []string{}
+	for _, item := range relatedwords {
+		word, errorword := item.(map[string]interface{})
+		if !errorword {
+			continue
+		}
+		name, _ := word["entry_name"].(string)
+		if name = StripMarkup(name); name != "" {
+			related = append(related, name)
+		}
+	}
+	return related, nil
+}
+
+// Scrape Romanisation and Hangul Pronunciation
+func GetPronunciations(searchinfo map[string]interface{}) (string, string, error) {
+	// Equivalent to searchInfo.entry.members[0].prons[0..1].show_pron_symbol
+	entry, errorentry := searchinfo["entry"].(map[string]interface{})
+	if !errorentry {
+		return "", "", errors.New("Cannot find entry in searchinfo")
+	}
+	members, errormembers := entry["members"].([]interface{})
+	if !errormembers || len(members) == 0 {
+		return "", "", errors.New("Cannot find members in entry")
+	}
+	member, errormember := members[0].(map[string]interface{})
+	if !errormember {
+		return "", "", errors.New("Cannot find member in members")
+	}
+	prons, errorprons := member["prons"].([]interface{})
+	if !errorprons || len(prons) == 0 {
+		return "", "", errors.New("Cannot find prons in member")
+	}
+	symbols := make([]string, 2)
+	for i := range symbols {
+		if i >= len(prons) {
+			break
+		}
+		pron, errorpron := prons[i].(map[string]interface{})
+		if !errorpron {
+			continue
+		}
+		symbols[i], _ = pron["show_pron_symbol"].(string)
+	}
+	return symbols[0], symbols[1], nil
+}
+
+// Scrape a Sense
+func GetSense(meaningitem map[string]interface{}, idx int) (Sense, error) {
+	sense := Sense{Number: idx + 1, Examples: []Markup{}}
+	meaning, errormeaning := meaningitem["show_mean"].(string)
+	if !errormeaning {
+		return Sense{}, errors.New("Cannot find ShowMean in meaningitem")
+	}
+	sense.Gloss = StripMarkup(meaning)
+
+	if part, found := meaningitem["part"].(map[string]interface{}); found {
+		sense.PartOfSpeech, _ = part["part_ko_name"].(string)
+		sense.PartOfSpeechEnglish, _ = part["part_en_name"].(string)
+		if sense.PartOfSpeechEnglish == "" {
+			sense.PartOfSpeechEnglish = partofspeechnames[sense.PartOfSpeech]
+		}
+	}
+
+	if descitem, found := meaningitem["description_json"].(string); found && descitem != "" {
+		var result map[string]interface{}
+		errordecode := json.Unmarshal([]byte(descitem), &result)
+		if errordecode != nil {
+			msg := fmt.Sprintf("Cannot decode DescriptionJSON of sense %d", sense.Number)
+			return Sense{}, errors.New(msg)
+		}
+		enstr, _ := result["en"].(string)
+		kostr, _ := result["ko"].(string)
+		sense.Definition = StripMarkup(enstr)
+		sense.KoreanDefinition = StripMarkup(kostr)
+	}
+
+	examples, _ := meaningitem["examples"].([]interface{})
+	for _, exampleitem := range examples {
+		example, errorexample := exampleitem.(map[string]interface{})
+		if !errorexample {
+			continue
+		}
+		originexample, errororiginexample := example["origin_example"].(string)
+		if !errororiginexample || originexample == "" {
+			continue
+		}
+		sense.Examples = append(sense.Examples, NormaliseMarkup(originexample))
+	}
+	return sense, nil
+}
+
+// Scrape Senses of the Word
+func GetSenses(searchinfo map[string]interface{}) ([]Sense, error) {
+	// Equivalent to searchInfo.entry.means.map(GetSense);
+	entry, errorentry := searchinfo["entry"].(map[string]interface{})
+	if !errorentry {
+		return nil, errors.New("Cannot find entry in searchinfo")
+	}
+	means, errormeans := entry["means"].([]interface{})
+	if !errormeans {
+		return nil, errors.New("Cannot find means in entry")
+	}
+	senses := make([]Sense, 0, len(means))
+	for _, meaning := range means {
+		meaningitem, errormeaningitem := meaning.(map[string]interface{})
+		if !errormeaningitem {
+			continue
+		}
+		sense, errsense := GetSense(meaningitem, len(senses))
+		if errsense != nil {
+			return nil, errsense
+		}
+		senses = append(senses, sense)
+	}
+	return senses, nil
+}
+
+// Scrape Naver entryId
+func GetId(searchinfo map[string]interface{}) (string, error) {
+	// Equivalent to searchInfo.entry.entry_id
+	entry, errorentry := searchinfo["entry"].(map[string]interface{})
+	if !errorentry {
+		return "", errors.New("Cannot find entry in searchinfo")
+	}
+	entryid, errorentryid := entry["entry_id"].(string)
+	if !errorentryid {
+		return "", errors.New("Cannot find entry_id in entry")
+	}
+	return entryid, nil
+}
+
+// Scrape Dictionary into an Entry
+func ScrapeEntry(searchinfo map[string]interface{}) (Entry, error) {
+	entry := Entry{Glosses: []string{}, Senses: []Sense{}}
+	entry.ID, _ = GetId(searchinfo)
+	entry.Title, _ = GetTitle(searchinfo)
+	entry.TopikLevel, _ = GetTopikLevel(searchinfo)
+	entry.Importance, _ = GetImportanceLevel(searchinfo)
+	entry.Hanja, _ = GetHanja(searchinfo)
+	entry.Romanisation, entry.Pronunciation, _ = GetPronunciations(searchinfo)
+	if glosses, errglosses := GetGlosses(searchinfo); errglosses == nil {
+		entry.Glosses = glosses
+	}
+	if senses, errsenses := GetSenses(searchinfo); errsenses == nil {
+		entry.Senses = senses
+	}
+	entry.Groups = GroupSenses(entry.Senses)
+	entry.Related, _ = GetRelated(searchinfo)
+	return HighlightHeadword(entry), nil
+}
+
+// Group Senses by part of speech, in order of first appearance.
+func GroupSenses(senses []Sense) []SenseGroup {
+	groups := []SenseGroup{}
+	found := map[string]int{}
+	for _, sense := range senses {
+		i, exists := found[sense.PartOfSpeech]
+		if !exists {
+			i = len(groups)
+			found[sense.PartOfSpeech] = i
+			groups = append(groups, SenseGroup{sense.PartOfSpeech, sense.PartOfSpeechEnglish, []int{}})
+		}
+		groups[i].Senses = append(groups[i].Senses, sense.Number)
+	}
+	return groups
+}
+
+// Convert an Entry into a DictInfo for messages.
+func (entry Entry) DictInfo() DictInfo {
+	return entry.DictInfoIn(DefaultLocale)
+}
+
+// Convert an Entry into a DictInfo for messages, with labels in a locale.
+func (entry Entry) DictInfoIn(locale string) DictInfo {
+	return entry.dictinfo(locale, false)
+}
+
+// Convert an Entry into a DictInfo, with the first or every example of
+// each sense.
+func (entry Entry) dictinfo(locale string, allexamples bool) DictInfo {
+	endefs := make([]string, len(entry.Glosses))
+	for i, gloss := range entry.Glosses {
+		endefs[i] = fmt.Sprintf("%d.%s", i+1, gloss)
+	}
+
+	pronun := ""
+	if entry.Romanisation != "" && entry.Pronunciation != "" {
+		pronun = fmt.Sprintf("[%s] [%s]", entry.Romanisation, entry.Pronunciation)
+	}
+
+	meanings := make(map[int]string, len(entry.Senses))
+	for _, sense := range entry.Senses {
+		lines := []string{fmt.Sprintf("%d.%s", sense.Number, sense.Gloss)}
+		if sense.Definition != "" {
+			lines = append(lines, sense.Definition)
+		}
+		if sense.KoreanDefinition != "" {
+			lines = append(lines, sense.KoreanDefinition)
+		}
+		for i, example := range sense.Examples {
+			if i > 0 && !allexamples {
+				break
+			}
+			lines = append(lines, "|| "+example.Text)
+		}
+		meanings[sense.Number] = strings.Join(lines, "\n")
+	}
+
+	// Senses of one part of speech keep the legacy layout. Otherwise each
+	// group is headed by its part of speech and separated like Buildmessage.
+	groups := entry.Groups
+	if groups == nil {
+		groups = GroupSenses(entry.Senses)
+	}
+	partspeeches := make([]string, 0, len(groups))
+	blocks := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if group.PartOfSpeech != "" {
+			partspeeches = append(partspeeches, group.PartOfSpeech)
+		}
+		groupmeanings := make([]string, 0, len(group.Senses))
+		for _, number := range group.Senses {
+			groupmeanings = append(groupmeanings, meanings[number])
+		}
+		block := strings.Join(groupmeanings, "\n\n")
+		if len(groups) > 1 && group.PartOfSpeech != "" {
+			block = group.PartOfSpeech + "\n" + block
+		}
+		blocks = append(blocks, block)
+	}
+
+	return DictInfo{
+		Topik:           entry.TopikLevel.LabelIn(locale),
+		Importance:      strings.Repeat("★", entry.Importance),
+		Title:           entry.Title,
+		Hanja:           entry.Hanja,
+		Endef:           strings.Join(endefs, " "),
+		Pronun:          pronun,
+		Partspeech:      strings.Join(partspeeches, ", "),
+		Meanings:        strings.Join(blocks, "\n----------\n"),
+		TopikLevel:      entry.TopikLevel,
+		ImportanceLevel: entry.Importance,
+	}
+}
@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScrapeEntryExample(t *testing.T) {
+	got, error := ScrapeEntry(examplesearchinfo)
+	want := Entry{
+		Title:         "강아지",
+		Hanja:         "奮發",
+		Romanisation:  "gang-a-ji",
+		Pronunciation: "강아지",
+		TopikLevel:    TopikElementary,
+		Importance:    2,
+		Glosses:       []string{"puppy", "small dog", "young dog"},
+		Senses: []Sense{{
+			Number:              1,
+			PartOfSpeech:        "명사",
+			PartOfSpeechEnglish: "noun",
+			Gloss:               "강아지",
+			Definition:          "a puppy or young dog",
+			KoreanDefinition:    "어린 개",
+			Examples:            []Markup{{Text: "강아지가 귀엽다", Spans: []Span{{0, 9, StyleHighlight}}}},
+		}},
+		Groups:  []SenseGroup{{"명사", "noun", []int{1}}},
+		Related: []string{"개", "멍멍이"},
+	}
+	if error != nil {
+		t.Errorf("ScrapeEntry(%q) = %q; want no error", examplesearchinfo, error)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScrapeEntry() = %+v; want %+v", got, want)
+	}
+}
+
+func TestEntryDictInfo(t *testing.T) {
+	entry, _ := ScrapeEntry(examplesearchinfo)
+	scraped, _ := Scrape(examplesearchinfo)
+	if entry.DictInfo() != scraped {
+		t.Errorf("Entry.DictInfo() = %+v; want %+v", entry.DictInfo(), scraped)
+	}
+}
+
+func TestGetSenseMissingFields(t *testing.T) {
+	// Senses without a description or examples are kept, unlike GetMeaning.
+	meaningitem := map[string]interface{}{
+		"show_mean": "<b>hello</b>",
+		"examples": []interface{}{
+			map[string]interface{}{"origin_example": ""},
+			map[string]interface{}{"origin_example": "<b>안녕</b>, 친구야."},
+		},
+	}
+	got, error := GetSense(meaningitem, 1)
+	want := Sense{
+		Number:   2,
+		Gloss:    "hello",
+		Examples: []Markup{{Text: "안녕, 친구야.", Spans: []Span{{0, 6, StyleBold}}}},
+	}
+	if error != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSense() = %+v, %v; want %+v", got, error, want)
+	}
+	dictinfo := Entry{Title: "안녕", Senses: []Sense{got}}.DictInfo()
+	if dictinfo.Meanings != "2.hello\n|| 안녕, 친구야." {
+		t.Errorf("Entry.DictInfo().Meanings = %q; want %q", dictinfo.Meanings, "2.hello\n|| 안녕, 친구야.")
+	}
+}
+
+func TestGetSensePartOfSpeech(t *testing.T) {
+	tests := []struct {
+		part map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"part_ko_name": "명사"}, "noun"},
+		{map[string]interface{}{"part_ko_name": "명사", "part_en_name": "Noun"}, "Noun"},
+		{map[string]interface{}{"part_ko_name": "의성 의태어", "part_en_name": "onomatopoeia"}, "onomatopoeia"},
+		{map[string]interface{}{"part_ko_name": "의성 의태어"}, ""},
+	}
+	for _, test := range tests {
+		meaningitem := map[string]interface{}{"show_mean": "meaning", "part": test.part}
+		got, error := GetSense(meaningitem, 0)
+		if error != nil || got.PartOfSpeechEnglish != test.want {
+			t.Errorf("GetSense(%v).PartOfSpeechEnglish = %q, %v; want %q", test.part, got.PartOfSpeechEnglish, error, test.want)
+		}
+	}
+}
+
+func TestEntryDictInfoGroups(t *testing.T) {
+	entry := Entry{
+		Title: "잘",
+		Senses: []Sense{
+			{Number: 1, PartOfSpeech: "부사", Gloss: "well"},
+			{Number: 2, PartOfSpeech: "명사", Gloss: "goodness"},
+			{Number: 3, PartOfSpeech: "부사", Gloss: "often"},
+		},
+	}
+	groups := GroupSenses(entry.Senses)
+	wantgroups := []SenseGroup{{"부사", "", []int{1, 3}}, {"명사", "", []int{2}}}
+	if !reflect.DeepEqual(groups, wantgroups) {
+		t.Errorf("GroupSenses() = %+v; want %+v", groups, wantgroups)
+	}
+	dictinfo := entry.DictInfo()
+	if dictinfo.Partspeech != "부사, 명사" {
+		t.Errorf("Entry.DictInfo().Partspeech = %q; want %q", dictinfo.Partspeech, "부사, 명사")
+	}
+	want := "부사\n1.well\n\n3.often\n----------\n명사\n2.goodness"
+	if dictinfo.Meanings != want {
+		t.Errorf("Entry.DictInfo().Meanings = %q; want %q", dictinfo.Meanings, want)
+	}
+}
@@ -0,0 +1,8 @@
+module naverdictionary/scraper/fonts/mkhanja
+
+go 1.23.0
+
+require (
+	golang.org/x/image v0.25.0
+	golang.org/x/text v0.23.0
+)
@@ -0,0 +1,518 @@
+// Mkhanja writes NotoSansCJKkr-Hanja.ttf, a TrueType font with the 4,888
+// hanja of KS X 1001 from the Korean face of Noto Sans CJK, for word cards
+// and vocabulary sheets. It is a module of its own, so that its
+// dependencies stay out of the service's:
+//
+//	cd scraper/fonts/mkhanja
+//	go run . -font NotoSansCJK-Bold.ttc
+//
+// Noto Sans CJK has cubic PostScript outlines, which are approximated with
+// quadratic TrueType outlines so that PDF documents can embed subsets of
+// the font like those of the other TrueType fonts.
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/encoding/korean"
+)
+
+// Maximum distance between a cubic curve and its quadratic approximation,
+// in font units.
+const tolerance = 0.5
+
+// Name of the font written.
+const (
+	family     = "Noto Sans CJK KR Hanja"
+	postscript = "NotoSansCJKkrHanja-Bold"
+)
+
+func main() {
+	fontpath := flag.String("font", "NotoSansCJK-Bold.ttc", "Noto Sans CJK collection (.ttc) or Korean font (.otf)")
+	output := flag.String("o", "../NotoSansCJKkr-Hanja.ttf", "TrueType font to write")
+	flag.Parse()
+
+	data, errread := os.ReadFile(*fontpath)
+	if errread != nil {
+		log.Fatal(errread)
+	}
+	source, tables, errsource := koreanface(data)
+	if errsource != nil {
+		log.Fatal(errsource)
+	}
+	hanja := kshanja()
+
+	var buffer sfnt.Buffer
+	upem := int(source.UnitsPerEm())
+	ppem := fixed.I(upem) // One pixel per font unit.
+	glyphs := [][]byte{{}}
+	advances := []int{upem}
+	bounds := [][4]int{{}}
+	mapping := map[rune]int{}
+	bysource := map[sfnt.GlyphIndex]int{}
+	maxpoints, maxcontours := 0, 0
+	for _, char := range hanja {
+		index, errindex := source.GlyphIndex(&buffer, char)
+		if errindex != nil || index == 0 {
+			log.Printf("missing %c (U+%04X)", char, char)
+			continue
+		}
+		if glyph, found := bysource[index]; found {
+			mapping[char] = glyph
+			continue
+		}
+		segments, errload := source.LoadGlyph(&buffer, index, ppem, nil)
+		if errload != nil {
+			log.Fatal(errload)
+		}
+		advance, erradvance := source.GlyphAdvance(&buffer, index, ppem, font.HintingNone)
+		if erradvance != nil {
+			log.Fatal(erradvance)
+		}
+		contours := outline(segments)
+		glyph, box, points := encodeglyph(contours)
+		maxpoints = max(maxpoints, points)
+		maxcontours = max(maxcontours, len(contours))
+		bysource[index] = len(glyphs)
+		mapping[char] = len(glyphs)
+		glyphs = append(glyphs, glyph)
+		advances = append(advances, int(math.Round(float64(advance)/64)))
+		bounds = append(bounds, box)
+	}
+
+	fonttables := map[string][]byte{}
+	glyf, loca := []byte{}, []byte{}
+	for _, glyph := range glyphs {
+		loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+		glyf = append(glyf, glyph...)
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+	}
+	loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+	fonttables["glyf"], fonttables["loca"] = glyf, loca
+
+	hmtx := []byte{}
+	box := [4]int{math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16}
+	maxadvance, minlsb, minrsb, maxextent, totaladvance := 0, math.MaxInt16, math.MaxInt16, math.MinInt16, 0
+	for i, advance := range advances {
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(advance))
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(int16(bounds[i][0])))
+		maxadvance = max(maxadvance, advance)
+		totaladvance += advance
+		if len(glyphs[i]) == 0 {
+			continue
+		}
+		box = [4]int{min(box[0], bounds[i][0]), min(box[1], bounds[i][1]), max(box[2], bounds[i][2]), max(box[3], bounds[i][3])}
+		minlsb = min(minlsb, bounds[i][0])
+		minrsb = min(minrsb, advance-bounds[i][2])
+		maxextent = max(maxextent, bounds[i][2])
+	}
+	fonttables["hmtx"] = hmtx
+
+	head := append([]byte{}, tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0) // checkSumAdjustment
+	for i, value := range box {
+		binary.BigEndian.PutUint16(head[36+2*i:], uint16(int16(value)))
+	}
+	binary.BigEndian.PutUint16(head[50:], 1) // indexToLocFormat: long offsets.
+	binary.BigEndian.PutUint16(head[52:], 0) // glyphDataFormat
+	fonttables["head"] = head
+
+	hhea := append([]byte{}, tables["hhea"]...)
+	binary.BigEndian.PutUint16(hhea[10:], uint16(maxadvance))
+	binary.BigEndian.PutUint16(hhea[12:], uint16(int16(minlsb)))
+	binary.BigEndian.PutUint16(hhea[14:], uint16(int16(minrsb)))
+	binary.BigEndian.PutUint16(hhea[16:], uint16(int16(maxextent)))
+	binary.BigEndian.PutUint16(hhea[34:], uint16(len(glyphs))) // numberOfHMetrics
+	fonttables["hhea"] = hhea
+
+	maxp := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	maxp = binary.BigEndian.AppendUint16(maxp, uint16(len(glyphs)))
+	maxp = binary.BigEndian.AppendUint16(maxp, uint16(maxpoints))
+	maxp = binary.BigEndian.AppendUint16(maxp, uint16(maxcontours))
+	maxp = append(maxp, make([]byte, 4)...)       // maxCompositePoints, maxCompositeContours
+	maxp = binary.BigEndian.AppendUint16(maxp, 2) // maxZones
+	maxp = append(maxp, make([]byte, 16)...)      // No instructions or composites.
+	fonttables["maxp"] = maxp
+
+	chars := make([]rune, 0, len(mapping))
+	for char := range mapping {
+		chars = append(chars, char)
+	}
+	sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+	fonttables["cmap"] = cmap(chars, mapping)
+
+	os2 := append([]byte{}, tables["OS/2"]...)
+	binary.BigEndian.PutUint16(os2[2:], uint16(totaladvance/len(advances))) // xAvgCharWidth
+	for i := 0; i < 4; i++ {
+		binary.BigEndian.PutUint32(os2[42+4*i:], 0) // ulUnicodeRange
+	}
+	binary.BigEndian.PutUint32(os2[46:], 1<<(59-32)|1<<(61-32)) // CJK Unified and Compatibility Ideographs.
+	binary.BigEndian.PutUint16(os2[64:], uint16(chars[0]))      // usFirstCharIndex
+	binary.BigEndian.PutUint16(os2[66:], uint16(chars[len(chars)-1]))
+	binary.BigEndian.PutUint32(os2[78:], 1<<19) // ulCodePageRange: Korean Wansung.
+	binary.BigEndian.PutUint32(os2[82:], 0)
+	fonttables["OS/2"] = os2
+
+	post := append([]byte{}, tables["post"][:32]...)
+	binary.BigEndian.PutUint32(post, 0x00030000) // No glyph names.
+	fonttables["post"] = post
+
+	copyright, _ := source.Name(&buffer, sfnt.NameIDCopyright)
+	version, _ := source.Name(&buffer, sfnt.NameIDVersion)
+	version, _, _ = strings.Cut(version, ";")
+	license, _ := source.Name(&buffer, sfnt.NameIDLicense)
+	fonttables["name"] = names(map[uint16]string{
+		0:  copyright,
+		1:  family,
+		2:  "Bold",
+		3:  version + ";" + postscript,
+		4:  family + " Bold",
+		5:  version + "; KS X 1001 hanja subset",
+		6:  postscript,
+		13: license,
+		14: "https://openfontlicense.org",
+	})
+
+	written := writefont(fonttables)
+	if errwrite := os.WriteFile(*output, written, 0o644); errwrite != nil {
+		log.Fatal(errwrite)
+	}
+	fmt.Printf("%s: %d characters, %d glyphs, %d bytes\n", *output, len(chars), len(glyphs), len(written))
+}
+
+// Find the Korean face of a collection, or use a single font, with its raw
+// tables.
+func koreanface(data []byte) (*sfnt.Font, map[string][]byte, error) {
+	offsets := []int{0}
+	if string(data[:4]) == "ttcf" {
+		offsets = offsets[:0]
+		for i := 0; i < int(binary.BigEndian.Uint32(data[8:])); i++ {
+			offsets = append(offsets, int(binary.BigEndian.Uint32(data[12+4*i:])))
+		}
+	}
+	collection, errparse := sfnt.ParseCollection(data)
+	if errparse != nil {
+		return nil, nil, errparse
+	}
+	var buffer sfnt.Buffer
+	for i, offset := range offsets {
+		face, errface := collection.Font(i)
+		if errface != nil {
+			return nil, nil, errface
+		}
+		name, _ := face.Name(&buffer, sfnt.NameIDFull)
+		if len(offsets) > 1 && !strings.HasPrefix(name, "Noto Sans CJK KR") {
+			continue
+		}
+		tables := map[string][]byte{}
+		for j := 0; j < int(binary.BigEndian.Uint16(data[offset+4:])); j++ {
+			record := data[offset+12+16*j:]
+			start, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+			tables[string(record[:4])] = data[start : start+length]
+		}
+		return face, tables, nil
+	}
+	return nil, nil, fmt.Errorf("no Noto Sans CJK KR face")
+}
+
+// The hanja of KS X 1001, rows 74 to 93 of EUC-KR, in code point order.
+func kshanja() []rune {
+	decoder := korean.EUCKR.NewDecoder()
+	hanja := []rune{}
+	for lead := 0xCA; lead <= 0xFD; lead++ {
+		for trail := 0xA1; trail <= 0xFE; trail++ {
+			decoded, errdecode := decoder.Bytes([]byte{byte(lead), byte(trail)})
+			char, _ := utf8.DecodeRune(decoded)
+			if errdecode == nil && char != utf8.RuneError {
+				hanja = append(hanja, char)
+			}
+		}
+	}
+	sort.Slice(hanja, func(i, j int) bool { return hanja[i] < hanja[j] })
+	return hanja
+}
+
+// A point of a TrueType contour, on the curve or a quadratic control point.
+type point struct {
+	x, y float64
+	on   bool
+}
+
+// Convert the segments of a glyph, with y pointing down, into TrueType
+// contours with y pointing up and clockwise outer contours.
+func outline(segments sfnt.Segments) [][]point {
+	contours := [][]point{}
+	current := []point{}
+	at := func(p fixed.Point26_6) point { return point{float64(p.X) / 64, -float64(p.Y) / 64, true} }
+	finish := func() {
+		if len(current) > 1 && current[0] == current[len(current)-1] {
+			current = current[:len(current)-1]
+		}
+		if len(current) > 2 {
+			contours = append(contours, current)
+		}
+		current = []point{}
+	}
+	for _, segment := range segments {
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			finish()
+			current = append(current, at(segment.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			current = append(current, at(segment.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			control := at(segment.Args[0])
+			control.on = false
+			current = append(current, control, at(segment.Args[1]))
+		case sfnt.SegmentOpCubeTo:
+			start := current[len(current)-1]
+			current = append(current, cubictoquads(start, at(segment.Args[0]), at(segment.Args[1]), at(segment.Args[2]))...)
+		}
+	}
+	finish()
+
+	// Round to font units, dropping points that fall together, and reverse
+	// PostScript's counter-clockwise outer contours.
+	for i, contour := range contours {
+		rounded := []point{}
+		for _, p := range contour {
+			p.x, p.y = math.Round(p.x), math.Round(p.y)
+			if len(rounded) > 0 && p.on && rounded[len(rounded)-1] == p {
+				continue
+			}
+			rounded = append(rounded, p)
+		}
+		for len(rounded) > 1 && rounded[len(rounded)-1].on && rounded[len(rounded)-1] == rounded[0] {
+			rounded = rounded[:len(rounded)-1]
+		}
+		reversed := []point{rounded[0]}
+		for j := len(rounded) - 1; j > 0; j-- {
+			reversed = append(reversed, rounded[j])
+		}
+		contours[i] = reversed
+	}
+	return contours
+}
+
+// Approximate a cubic curve with quadratic ones, returning the control
+// and end point of each.
+func cubictoquads(p0, p1, p2, p3 point) []point {
+	// The error of the best single quadratic is at most √3/36 of the
+	// length of the third difference of the cubic.
+	dx, dy := p3.x-3*p2.x+3*p1.x-p0.x, p3.y-3*p2.y+3*p1.y-p0.y
+	if math.Sqrt(3)/36*math.Hypot(dx, dy) <= tolerance {
+		control := point{(3*(p1.x+p2.x) - p0.x - p3.x) / 4, (3*(p1.y+p2.y) - p0.y - p3.y) / 4, false}
+		return []point{control, p3}
+	}
+	mid := func(a, b point) point { return point{(a.x + b.x) / 2, (a.y + b.y) / 2, true} }
+	p01, p12, p23 := mid(p0, p1), mid(p1, p2), mid(p2, p3)
+	p012, p123 := mid(p01, p12), mid(p12, p23)
+	split := mid(p012, p123)
+	return append(cubictoquads(p0, p01, p012, split), cubictoquads(split, p123, p23, p3)...)
+}
+
+// Encode a simple TrueType glyph. Returns the glyph, its bounding box and
+// its number of points.
+func encodeglyph(contours [][]point) ([]byte, [4]int, int) {
+	if len(contours) == 0 {
+		return []byte{}, [4]int{}, 0
+	}
+	box := [4]int{math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16}
+	ends := []byte{}
+	flags, xs, ys := []byte{}, []byte{}, []byte{}
+	count, lastx, lasty := 0, 0, 0
+	for _, contour := range contours {
+		for _, p := range contour {
+			x, y := int(p.x), int(p.y)
+			box = [4]int{min(box[0], x), min(box[1], y), max(box[2], x), max(box[3], y)}
+			flag := byte(0)
+			if p.on {
+				flag |= 0x01
+			}
+			coordinate := func(delta int, short byte, same byte, output []byte) []byte {
+				switch {
+				case delta == 0:
+					flag |= same
+				case delta > -256 && delta < 256:
+					flag |= short
+					if delta > 0 {
+						flag |= same // Positive.
+					} else {
+						delta = -delta
+					}
+					output = append(output, byte(delta))
+				default:
+					output = binary.BigEndian.AppendUint16(output, uint16(int16(delta)))
+				}
+				return output
+			}
+			xs = coordinate(x-lastx, 0x02, 0x10, xs)
+			ys = coordinate(y-lasty, 0x04, 0x20, ys)
+			flags = append(flags, flag)
+			lastx, lasty = x, y
+			count++
+		}
+		ends = binary.BigEndian.AppendUint16(ends, uint16(count-1))
+	}
+	glyph := binary.BigEndian.AppendUint16(nil, uint16(len(contours)))
+	for _, value := range box {
+		glyph = binary.BigEndian.AppendUint16(glyph, uint16(int16(value)))
+	}
+	glyph = append(glyph, ends...)
+	glyph = binary.BigEndian.AppendUint16(glyph, 0) // No instructions.
+	glyph = append(glyph, flags...)
+	glyph = append(glyph, xs...)
+	glyph = append(glyph, ys...)
+	return glyph, box, count
+}
+
+// A cmap with a format 4 subtable for Unicode, of segments of consecutive
+// characters with consecutive glyphs.
+func cmap(chars []rune, mapping map[rune]int) []byte {
+	type segment struct{ start, end rune }
+	segments := []segment{}
+	for _, char := range chars {
+		last := len(segments) - 1
+		if last >= 0 && segments[last].end == char-1 && mapping[char]-int(char) == mapping[segments[last].start]-int(segments[last].start) {
+			segments[last].end = char
+			continue
+		}
+		segments = append(segments, segment{char, char})
+	}
+	segments = append(segments, segment{0xFFFF, 0xFFFF})
+
+	count := len(segments)
+	selector := 0
+	for 2<<selector <= count {
+		selector++
+	}
+	subtable := binary.BigEndian.AppendUint16(nil, 4)
+	subtable = binary.BigEndian.AppendUint16(subtable, uint16(16+8*count))
+	subtable = binary.BigEndian.AppendUint16(subtable, 0) // language
+	subtable = binary.BigEndian.AppendUint16(subtable, uint16(2*count))
+	subtable = binary.BigEndian.AppendUint16(subtable, uint16(2<<selector))
+	subtable = binary.BigEndian.AppendUint16(subtable, uint16(selector))
+	subtable = binary.BigEndian.AppendUint16(subtable, uint16(2*count-2<<selector))
+	for _, segment := range segments {
+		subtable = binary.BigEndian.AppendUint16(subtable, uint16(segment.end))
+	}
+	subtable = binary.BigEndian.AppendUint16(subtable, 0) // reservedPad
+	for _, segment := range segments {
+		subtable = binary.BigEndian.AppendUint16(subtable, uint16(segment.start))
+	}
+	for _, segment := range segments {
+		delta := 1 // 0xFFFF maps to glyph 0.
+		if segment.start != 0xFFFF {
+			delta = mapping[segment.start] - int(segment.start)
+		}
+		subtable = binary.BigEndian.AppendUint16(subtable, uint16(delta))
+	}
+	subtable = append(subtable, make([]byte, 2*count)...) // idRangeOffset
+
+	table := binary.BigEndian.AppendUint16(nil, 0) // version
+	table = binary.BigEndian.AppendUint16(table, 1)
+	table = binary.BigEndian.AppendUint16(table, 3) // Windows
+	table = binary.BigEndian.AppendUint16(table, 1) // Unicode BMP
+	table = binary.BigEndian.AppendUint32(table, 12)
+	return append(table, subtable...)
+}
+
+// A name table with Windows English names.
+func names(records map[uint16]string) []byte {
+	ids := []int{}
+	for id := range records {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	table := binary.BigEndian.AppendUint16(nil, 0) // format
+	table = binary.BigEndian.AppendUint16(table, uint16(len(ids)))
+	table = binary.BigEndian.AppendUint16(table, uint16(6+12*len(ids)))
+	storage := []byte{}
+	for _, id := range ids {
+		encoded := []byte{}
+		for _, unit := range utf16(records[uint16(id)]) {
+			encoded = binary.BigEndian.AppendUint16(encoded, unit)
+		}
+		for _, value := range []int{3, 1, 0x409, id, len(encoded), len(storage)} {
+			table = binary.BigEndian.AppendUint16(table, uint16(value))
+		}
+		storage = append(storage, encoded...)
+	}
+	return append(table, storage...)
+}
+
+// Encode text in UTF-16.
+func utf16(text string) []uint16 {
+	units := []uint16{}
+	for _, char := range text {
+		if char > 0xFFFF {
+			char -= 0x10000
+			units = append(units, uint16(0xD800+char>>10), uint16(0xDC00+char&0x3FF))
+			continue
+		}
+		units = append(units, uint16(char))
+	}
+	return units
+}
+
+// Write a TrueType font of tables, in tag order, with checksums.
+func writefont(tables map[string][]byte) []byte {
+	tags := []string{}
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	selector := 0
+	for 2<<selector <= len(tags) {
+		selector++
+	}
+	written := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	written = binary.BigEndian.AppendUint16(written, uint16(len(tags)))
+	written = binary.BigEndian.AppendUint16(written, uint16(16<<selector))
+	written = binary.BigEndian.AppendUint16(written, uint16(selector))
+	written = binary.BigEndian.AppendUint16(written, uint16(16*len(tags)-16<<selector))
+	body := []byte{}
+	headoffset := 0
+	for _, tag := range tags {
+		table := tables[tag]
+		offset := 12 + 16*len(tags) + len(body)
+		if tag == "head" {
+			headoffset = offset
+		}
+		written = append(written, tag...)
+		written = binary.BigEndian.AppendUint32(written, checksum(table))
+		written = binary.BigEndian.AppendUint32(written, uint32(offset))
+		written = binary.BigEndian.AppendUint32(written, uint32(len(table)))
+		body = append(body, table...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	written = append(written, body...)
+	binary.BigEndian.PutUint32(written[headoffset+8:], 0xB1B0AFBA-checksum(written))
+	return written
+}
+
+// Sum of the big-endian 32-bit words of a table, padded with zeros.
+func checksum(table []byte) uint32 {
+	sum := uint32(0)
+	for i := 0; i < len(table); i += 4 {
+		word := [4]byte{}
+		copy(word[:], table[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,226 @@
+package scraper
+
+import (
+	"sort"
+	"strings"
+)
+
+// Parts of speech whose headwords are conjugated, e.g. 가다 as 갔어요.
+var predicates = map[string]bool{
+	"동사":     true,
+	"보조 동사":  true,
+	"보조동사":   true,
+	"형용사":    true,
+	"보조 형용사": true,
+	"보조형용사":  true,
+}
+
+// First syllables of endings that follow a verb or adjective stem of more
+// than one syllable, e.g. 고 of 마시고 or 습 of 받습니다.
+const endingstarts = "다고게지는니면며서도요세시셔셨어아았었여였기음을은으겠던든데더자죠네냐라러래려워와우습"
+
+// Whole endings after the last syllable of a single-syllable stem, e.g. 요
+// of 가요 or 었어요 of 먹었어요. Single-syllable stems start many unrelated
+// words, e.g. 가을 for 가다, so their endings are matched whole, leaving
+// out 게, 지 and 면, which also end nouns such as 가게, 가지 and 가면.
+var stemendings = wordset("다 요 고 는 니 며 서 도 자 죠 네 냐 기 던 든 " +
+	"세요 셔요 셨다 셨어요 시고 시는 시면 십니다 십시오 지만 지요 는데 는데요 네요 고요 군요 는군요 " +
+	"겠다 겠어요 겠습니다 니다 니까 니까요 습니다 습니까 면서 면요 게요 까요 래요 " +
+	"어 아 여 어요 아요 여요 어서 아서 어도 아도 어야 아야 어라 아라 " +
+	"었다 았다 였다 었어 았어 었어요 았어요 였어요 었습니다 았습니다 었고 았고 었는데 았는데 었지만 았지만 " +
+	"워 워요 워서 워도 웠다 웠어요 웠습니다 운 울 우면 우니까")
+
+// Whole endings with 으 that follow only a stem ending in a consonant, e.g.
+// 먹을 or 들으면, not 가을.
+var consonantendings = wordset("으면 으니까 으니 으며 으세요 으셨어요 으러 으려고 으면서 " +
+	"은 을 음 은데 을까 을까요 을게요 을래요")
+
+func wordset(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// Vowels of a stem's last syllable contracted with the 아/어 of an ending,
+// e.g. ㅗ of 오 in 와요, or changed by an ㅎ irregular, e.g. 파랗다 as 파래.
+var contractedvowels = map[rune]string{
+	'ㅏ': "ㅐ",
+	'ㅑ': "ㅒ",
+	'ㅓ': "ㅐ",
+	'ㅗ': "ㅘ",
+	'ㅜ': "ㅝ",
+	'ㅚ': "ㅙ",
+	'ㅡ': "ㅓㅏ",
+	'ㅣ': "ㅕ",
+}
+
+// Finals that endings add to a stem's last syllable, e.g. ㅆ of 갔다, or
+// that irregular stems change to, e.g. ㄹ of 들어 from 듣다.
+const conjugatedfinals = " ㄴㄹㅁㅂㅆ"
+
+// Highlight the headword in the examples of an Entry, including conjugated
+// forms of verbs and adjectives, e.g. 갔어요 for 가다, and nouns with
+// particles attached, e.g. 안녕을. Existing emphasis is kept, and forms it
+// overlaps are not highlighted again.
+func HighlightHeadword(entry Entry) Entry {
+	headword := []rune{}
+	for _, char := range entry.Title {
+		if ishangul(char) {
+			headword = append(headword, char)
+		}
+	}
+	if len(headword) == 0 {
+		return entry
+	}
+	predicate := false
+	for _, sense := range entry.Senses {
+		predicate = predicate || predicates[sense.PartOfSpeech]
+	}
+	if predicate && len(headword) > 1 && headword[len(headword)-1] == '다' {
+		headword = headword[:len(headword)-1]
+	} else {
+		predicate = false
+	}
+
+	senses := make([]Sense, len(entry.Senses))
+	for i, sense := range entry.Senses {
+		examples := make([]Markup, len(sense.Examples))
+		for j, example := range sense.Examples {
+			examples[j] = highlightmarkup(headword, predicate, example)
+		}
+		sense.Examples = examples
+		senses[i] = sense
+	}
+	entry.Senses = senses
+	return entry
+}
+
+// Add highlight Spans to the words of a Markup that are forms of a
+// headword, or of a stem if predicate.
+func highlightmarkup(headword []rune, predicate bool, markup Markup) Markup {
+	spans := append([]Span{}, markup.Spans...)
+	added := false
+	start := -1
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if matched := matchheadword(headword, predicate, word); matched > 0 {
+			span := Span{start, start + len(string(word[:matched])), StyleHighlight}
+			overlaps := false
+			for _, existing := range markup.Spans {
+				overlaps = overlaps || (existing.Start < span.End && span.Start < existing.End)
+			}
+			if !overlaps {
+				spans = append(spans, span)
+				added = true
+			}
+		}
+		word = word[:0]
+	}
+	for offset, char := range markup.Text {
+		if !ishangul(char) {
+			flush()
+			continue
+		}
+		if len(word) == 0 {
+			start = offset
+		}
+		word = append(word, char)
+	}
+	flush()
+	if !added {
+		return markup
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return Markup{Text: markup.Text, Spans: spans}
+}
+
+// Number of leading syllables of a word to highlight as a form of the
+// headword: the headword of a noun, without its particles, or the whole
+// word of a stem, with its ending. 0 if the word is not a form of it.
+// Endings of single-syllable stems must be whole stemendings.
+func matchheadword(headword []rune, predicate bool, word []rune) int {
+	count := len(headword)
+	if !predicate {
+		if headwordat(string(headword), string(word)) > 0 {
+			return count
+		}
+		return 0
+	}
+	if len(word) < count {
+		return 0
+	}
+
+	last, conjugated := headword[count-1], word[count-1]
+	if string(word[:count-1]) == string(headword[:count-1]) && conjugates(last, conjugated) {
+		rest := word[count:]
+		if len(rest) == 0 {
+			return len(word)
+		}
+		if count == 1 {
+			_, _, final, _ := splitsyllable(conjugated)
+			if stemendings[string(rest)] || (final != ' ' && consonantendings[string(rest)]) {
+				return len(word)
+			}
+		} else if strings.ContainsRune(endingstarts, rest[0]) {
+			return len(word)
+		}
+	}
+	// 르 irregular, e.g. 모르다 as 몰라요.
+	if last == '르' && count > 1 && string(word[:count-2]) == string(headword[:count-2]) {
+		initial, medial, final, _ := splitsyllable(headword[count-2])
+		if final == ' ' && word[count-2] == joinsyllable(initial, medial, 'ㄹ') && (conjugated == '라' || conjugated == '러') {
+			return len(word)
+		}
+	}
+	return 0
+}
+
+// Whether a syllable is a conjugated form of the last syllable of a stem,
+// e.g. 갔 of 가 or 와 of 오.
+func conjugates(last rune, conjugated rune) bool {
+	if last == conjugated {
+		return true
+	}
+	initial, medial, final, found := splitsyllable(last)
+	conjugatedinitial, conjugatedmedial, conjugatedfinal, conjugatedfound := splitsyllable(conjugated)
+	if !found || !conjugatedfound || initial != conjugatedinitial {
+		return false
+	}
+	if medial == conjugatedmedial {
+		return strings.ContainsRune(conjugatedfinals, conjugatedfinal)
+	}
+	contracted := strings.ContainsRune(contractedvowels[medial], conjugatedmedial)
+	return contracted && (final == ' ' || final == 'ㅎ') && (conjugatedfinal == ' ' || conjugatedfinal == 'ㅆ')
+}
+
+func ishangul(char rune) bool {
+	return char >= '가' && char <= '힣'
+}
+
+// Split a Hangul syllable into its initial, medial and final jamo, e.g. 갔
+// into ㄱ, ㅏ and ㅆ. The final is ' ' if there is none.
+func splitsyllable(char rune) (rune, rune, rune, bool) {
+	if !ishangul(char) {
+		return 0, 0, 0, false
+	}
+	index := int(char - '가')
+	return initialjamo[index/(21*28)], medialjamo[(index%(21*28))/28], finaljamo[index%28], true
+}
+
+// Join jamo into a Hangul syllable, the reverse of splitsyllable.
+func joinsyllable(initial rune, medial rune, final rune) rune {
+	indexof := func(jamo []rune, char rune) int {
+		for i, candidate := range jamo {
+			if candidate == char {
+				return i
+			}
+		}
+		return 0
+	}
+	return '가' + rune((indexof(initialjamo, initial)*21+indexof(medialjamo, medial))*28+indexof(finaljamo, final))
+}
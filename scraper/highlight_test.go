@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchHeadword(t *testing.T) {
+	tests := []struct {
+		headword  string
+		predicate bool
+		word      string
+		want      int
+	}{
+		{"안녕", false, "안녕을", 2},
+		{"안녕", false, "안녕", 2},
+		{"안녕", false, "편안", 0},
+		{"물", false, "물을", 1},
+		{"물", false, "물건", 0},
+		{"개", false, "개월", 0},
+		{"가", true, "가요", 2},
+		{"가", true, "갔어요", 3},
+		{"가", true, "갑니다", 3},
+		{"가", true, "가족", 0},
+		{"가", true, "가을", 0},
+		{"가", true, "가게", 0},
+		{"가", true, "가지", 0},
+		{"가", true, "가면", 0},
+		{"가", true, "가고", 2},
+		{"먹", true, "먹었어요", 4},
+		{"먹", true, "먹을", 2},
+		{"듣", true, "들으면", 3},
+		{"먹", true, "먹습니다", 4},
+		{"먹", true, "먹는", 2},
+		{"오", true, "와요", 2},
+		{"마시", true, "마셨다", 3},
+		{"공부하", true, "공부해요", 4},
+		{"듣", true, "들어요", 3},
+		{"춥", true, "추워요", 3},
+		{"모르", true, "몰라요", 3},
+		{"예쁘", true, "예뻐요", 3},
+		{"모르", true, "모래", 0},
+	}
+	for _, test := range tests {
+		got := matchheadword([]rune(test.headword), test.predicate, []rune(test.word))
+		if got != test.want {
+			t.Errorf("matchheadword(%q, %v, %q) = %d; want %d", test.headword, test.predicate, test.word, got, test.want)
+		}
+	}
+}
+
+func TestHighlightHeadword(t *testing.T) {
+	entry := Entry{
+		Title: "가다",
+		Senses: []Sense{{Number: 1, PartOfSpeech: "동사", Examples: []Markup{
+			{Text: "학교에 갔어요. 가족도 가요."},
+			{Text: "집에 가다.", Spans: []Span{{7, 13, StyleBold}}},
+		}}},
+	}
+	got := HighlightHeadword(entry).Senses[0].Examples
+	want := []Markup{
+		{Text: "학교에 갔어요. 가족도 가요.", Spans: []Span{{10, 19, StyleHighlight}, {31, 37, StyleHighlight}}},
+		{Text: "집에 가다.", Spans: []Span{{7, 13, StyleBold}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HighlightHeadword() examples = %+v; want %+v", got, want)
+	}
+	if len(entry.Senses[0].Examples[0].Spans) != 0 {
+		t.Errorf("HighlightHeadword() changed the examples of its argument")
+	}
+
+	// 바다 is a noun, so 바 is not a stem.
+	noun := Entry{Title: "바다", Senses: []Sense{{Number: 1, PartOfSpeech: "명사", Examples: []Markup{{Text: "바다를 봐요."}}}}}
+	spans := HighlightHeadword(noun).Senses[0].Examples[0].Spans
+	if !reflect.DeepEqual(spans, []Span{{0, 6, StyleHighlight}}) {
+		t.Errorf("HighlightHeadword(바다) spans = %+v; want the noun without its particle", spans)
+	}
+}
+
+func TestHighlightRendered(t *testing.T) {
+	entry := HighlightHeadword(Entry{Title: "가다", Senses: []Sense{{Number: 1, PartOfSpeech: "동사", Examples: []Markup{{Text: "집에 가요."}}}}})
+	example := entry.Senses[0].Examples[0]
+	tests := map[*Format]string{
+		MarkdownV2:   "집에 __가요__\\.",
+		TelegramHTML: "집에 <u>가요</u>.",
+		CommonMark:   "집에 **가요**\\.",
+	}
+	for format, want := range tests {
+		got := example.Render(format.Escape, format.Wrap)
+		if got != want {
+			t.Errorf("%s: Render() = %q; want %q", format.Name(), got, want)
+		}
+	}
+}
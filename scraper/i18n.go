@@ -0,0 +1,208 @@
+package scraper
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locales with a message catalog.
+const (
+	English  = "en"
+	Korean   = "ko"
+	Chinese  = "zh"
+	Japanese = "ja"
+)
+
+// Locale used when none is requested or supported.
+var DefaultLocale = English
+
+// Keys of the message catalog.
+const (
+	LabelPronunciation     = "pronunciation"
+	LabelRomanisation      = "romanisation" // Before the romanised pronunciation in plain text messages.
+	LabelTopikElementary   = "topik_elementary"
+	LabelTopikIntermediate = "topik_intermediate"
+	LabelVocabulary        = "vocabulary" // Default title of a vocabulary sheet.
+	LabelWord              = "word"
+	LabelHanja             = "hanja"
+	LabelReading           = "reading"
+	LabelMeaning           = "meaning"
+	LabelExample           = "example"
+	LabelRelated           = "related"     // Before related words in full messages.
+	LabelName              = "name"        // For students to write their name on a quiz.
+	MessageWelcome         = "welcome"     // Reply to a search term without Korean or English letters.
+	MessageApiWelcome      = "api_welcome" // Welcome page of the REST API.
+	MessageNoMatch         = "no_match"    // A word does not match a REST filter.
+)
+
+// Translations of every key, by locale.
+var catalog = map[string]map[string]string{
+	English: {
+		LabelPronunciation:     "Pronunciation:",
+		LabelRomanisation:      "roma",
+		LabelTopikElementary:   "(TOPIK Elementary)",
+		LabelTopikIntermediate: "(TOPIK Intermediate)",
+		LabelVocabulary:        "Vocabulary",
+		LabelWord:              "Word",
+		LabelHanja:             "Hanja",
+		LabelReading:           "Reading",
+		LabelMeaning:           "Meaning",
+		LabelExample:           "Example",
+		LabelRelated:           "Related:",
+		LabelName:              "Name:",
+		MessageWelcome:         "Welcome to NaverDict Bot! Please enter a Korean word to search (e.g. 나무).",
+		MessageApiWelcome:      "Welcome to the Naver Scraper API!",
+		MessageNoMatch:         "word does not match the 'topik' or 'min_importance' filter",
+	},
+	Korean: {
+		LabelPronunciation:     "발음:",
+		LabelRomanisation:      "로마자",
+		LabelTopikElementary:   "(TOPIK 초급)",
+		LabelTopikIntermediate: "(TOPIK 중급)",
+		LabelVocabulary:        "어휘",
+		LabelWord:              "단어",
+		LabelHanja:             "한자",
+		LabelReading:           "발음",
+		LabelMeaning:           "뜻",
+		LabelExample:           "예문",
+		LabelRelated:           "관련어:",
+		LabelName:              "이름:",
+		MessageWelcome:         "NaverDict 봇에 오신 것을 환영합니다! 검색할 한국어 단어를 입력해 주세요 (예: 나무).",
+		MessageApiWelcome:      "Naver Scraper API에 오신 것을 환영합니다!",
+		MessageNoMatch:         "단어가 'topik' 또는 'min_importance' 조건에 맞지 않습니다",
+	},
+	Chinese: {
+		LabelPronunciation:     "发音：",
+		LabelRomanisation:      "罗马字",
+		LabelTopikElementary:   "(TOPIK 初级)",
+		LabelTopikIntermediate: "(TOPIK 中级)",
+		LabelVocabulary:        "词汇",
+		LabelWord:              "单词",
+		LabelHanja:             "汉字",
+		LabelReading:           "读音",
+		LabelMeaning:           "释义",
+		LabelExample:           "例句",
+		LabelRelated:           "相关词：",
+		LabelName:              "姓名：",
+		MessageWelcome:         "欢迎使用 NaverDict 机器人！请输入要查询的韩语单词（例如：나무）。",
+		MessageApiWelcome:      "欢迎使用 Naver Scraper API！",
+		MessageNoMatch:         "单词不符合 'topik' 或 'min_importance' 筛选条件",
+	},
+	Japanese: {
+		LabelPronunciation:     "発音：",
+		LabelRomanisation:      "ローマ字",
+		LabelTopikElementary:   "(TOPIK 初級)",
+		LabelTopikIntermediate: "(TOPIK 中級)",
+		LabelVocabulary:        "語彙",
+		LabelWord:              "単語",
+		LabelHanja:             "漢字",
+		LabelReading:           "読み",
+		LabelMeaning:           "意味",
+		LabelExample:           "例文",
+		LabelRelated:           "関連語：",
+		LabelName:              "名前：",
+		MessageWelcome:         "NaverDict Botへようこそ！検索する韓国語の単語を入力してください（例：나무）。",
+		MessageApiWelcome:      "Naver Scraper APIへようこそ！",
+		MessageNoMatch:         "単語が 'topik' または 'min_importance' の条件に一致しません",
+	},
+}
+
+// Translate a catalog key into a locale, falling back to the DefaultLocale
+// and then English.
+func Translate(locale string, key string) string {
+	for _, candidate := range []string{locale, DefaultLocale, English} {
+		if translation, found := catalog[candidate][key]; found {
+			return translation
+		}
+	}
+	return key
+}
+
+// Locales with a message catalog, sorted.
+func Locales() []string {
+	locales := make([]string, 0, len(catalog))
+	for locale := range catalog {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Match a language tag, e.g. ko-KR or Telegram's language_code, or an
+// Accept-Language header, e.g. ko-KR,ko;q=0.9,en;q=0.8, to the best
+// supported locale. Returns the DefaultLocale if none is supported.
+func MatchLocale(languages string) string {
+	type weighted struct {
+		locale string
+		weight float64
+	}
+	candidates := []weighted{}
+	for _, language := range strings.Split(languages, ",") {
+		tag, parameters, _ := strings.Cut(strings.TrimSpace(language), ";")
+		weight := 1.0
+		if quality, found := strings.CutPrefix(strings.TrimSpace(parameters), "q="); found {
+			parsed, errparse := strconv.ParseFloat(quality, 64)
+			if errparse == nil {
+				weight = parsed
+			}
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		primary, _, _ = strings.Cut(primary, "_")
+		if _, found := catalog[primary]; found && weight > 0 {
+			candidates = append(candidates, weighted{primary, weight})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLocale
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weight > candidates[j].weight
+	})
+	return candidates[0].locale
+}
+
+// LocalisedRenderer is a Renderer that can render in a locale.
+type LocalisedRenderer interface {
+	Renderer
+	RenderIn(locale string, entry Entry) string
+}
+
+// configured renders with a Renderer in a fixed locale and at a fixed
+// Detail, if any.
+type configured struct {
+	Renderer
+	locale string
+	detail Detail
+}
+
+func (renderer configured) Render(entry Entry) string {
+	switch inner := renderer.Renderer.(type) {
+	case DetailedRenderer:
+		detail := renderer.detail
+		if detail == "" {
+			detail = DefaultDetail
+		}
+		return inner.RenderDetail(renderer.locale, detail, entry)
+	case LocalisedRenderer:
+		return inner.RenderIn(renderer.locale, renderer.detail.Apply(entry))
+	}
+	return renderer.Renderer.Render(renderer.detail.Apply(entry))
+}
+
+func (renderer configured) Execute(entry Entry) (string, error) {
+	if checked, found := renderer.Renderer.(CheckedRenderer); found {
+		return checked.Execute(renderer.detail.Apply(entry))
+	}
+	return renderer.Render(entry), nil
+}
+
+// Render with a Renderer in a locale. Renderers that are not
+// LocalisedRenderers, e.g. templates, render as usual.
+func Localise(renderer Renderer, locale string) Renderer {
+	if configured, found := renderer.(configured); found {
+		configured.locale = locale
+		return configured
+	}
+	return configured{renderer, locale, ""}
+}
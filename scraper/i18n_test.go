@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchLocale(t *testing.T) {
+	tests := map[string]string{
+		"":                           English,
+		"ko":                         Korean,
+		"ko-KR,ko;q=0.9,en;q=0.8":    Korean,
+		"fr-FR,ja;q=0.5,zh-CN;q=0.7": Chinese,
+		"zh-hans":                    Chinese, // Telegram language_code.
+		"pt-br":                      English,
+		"en;q=0.1, ja-JP":            Japanese,
+		"ko;q=0":                     English,
+	}
+	for languages, want := range tests {
+		got := MatchLocale(languages)
+		if got != want {
+			t.Errorf("MatchLocale(%q) = %q; want %q", languages, got, want)
+		}
+	}
+}
+
+func TestCatalogComplete(t *testing.T) {
+	for _, locale := range Locales() {
+		for key := range catalog[English] {
+			if catalog[locale][key] == "" {
+				t.Errorf("catalog[%q][%q] is missing", locale, key)
+			}
+		}
+	}
+}
+
+func TestTranslateFallback(t *testing.T) {
+	got := Translate("fr", LabelPronunciation)
+	if got != "Pronunciation:" {
+		t.Errorf("Translate(%q, %q) = %q; want %q", "fr", LabelPronunciation, got, "Pronunciation:")
+	}
+}
+
+func TestBuildmessageIn(t *testing.T) {
+	entry, _ := ScrapeEntry(examplesearchinfo)
+	got := BuildmessageIn(Korean, entry.DictInfoIn(Korean))
+	if !strings.HasPrefix(got, "(TOPIK 초급) ★★\n") || !strings.Contains(got, "\n발음:\n로마자 ") {
+		t.Errorf("BuildmessageIn(%q) = %q; want Korean labels", Korean, got)
+	}
+	if Buildmessage(entry.DictInfo()) != PlainText.Render(entry) {
+		t.Errorf("Buildmessage() = %q; want English labels", Buildmessage(entry.DictInfo()))
+	}
+}
+
+func TestLocalise(t *testing.T) {
+	got := Localise(MarkdownV2, Japanese).Render(renderentry)
+	if !strings.HasPrefix(got, "\\(TOPIK 初級\\)") || !strings.Contains(got, "_発音：_") {
+		t.Errorf("Localise(MarkdownV2, %q).Render() = %q; want Japanese labels", Japanese, got)
+	}
+	template, _ := NewTemplateRenderer("title", "{{.Title}}", nil)
+	got = Localise(template, Japanese).Render(renderentry)
+	if got != "안녕" {
+		t.Errorf("Localise(template, %q).Render() = %q; want %q", Japanese, got, "안녕")
+	}
+}
+
+func TestWelcomeError(t *testing.T) {
+	_, error := GetEntryInfoRaw("123")
+	if error != ErrWelcome {
+		t.Errorf("GetEntryInfoRaw(%q) = %v; want ErrWelcome", "123", error)
+	}
+}
@@ -0,0 +1,208 @@
+package scraper
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Styles of emphasis spans.
+const (
+	StyleBold      = "bold"      // <b>, <strong>
+	StyleItalic    = "italic"    // <i>, <em>
+	StyleUnderline = "underline" // <u>
+	StyleHighlight = "highlight" // <mark>, or a <span> with a highlight class.
+)
+
+// Span is an emphasised part of a Markup's Text.
+type Span struct {
+	Start int    `json:"start"` // Byte offset into Text.
+	End   int    `json:"end"`   // Byte offset into Text, exclusive.
+	Style string `json:"style"`
+}
+
+// Markup is a Naver string with its HTML stripped, e.g. show_mean or
+// origin_example. Emphasis is kept as Spans, so that each output format
+// can re-emit it in its own syntax.
+type Markup struct {
+	Text  string `json:"text"`
+	Spans []Span `json:"spans,omitempty"`
+}
+
+var tagpattern = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)([^<>]*)>`)
+
+var classpattern = regexp.MustCompile(`class\s*=\s*["']?([^"'>]*)`)
+
+// Get the emphasis style of an HTML tag, or "" if it is not emphasis.
+func tagstyle(name string, attributes string) string {
+	switch strings.ToLower(name) {
+	case "b", "strong":
+		return StyleBold
+	case "i", "em":
+		return StyleItalic
+	case "u":
+		return StyleUnderline
+	case "mark":
+		return StyleHighlight
+	case "span":
+		// Naver marks the searched word with e.g. <span class="highlight">.
+		class := classpattern.FindStringSubmatch(attributes)
+		if class != nil && (strings.Contains(class[1], "highlight") || strings.Contains(class[1], "point")) {
+			return StyleHighlight
+		}
+	}
+	return ""
+}
+
+// markupbuilder collapses whitespace while recording span offsets.
+type markupbuilder struct {
+	text   strings.Builder
+	spaced bool // Whether a space is pending before the next character.
+	spans  []Span
+	opened []opentag
+}
+
+// opentag is a tag waiting for its closing tag.
+type opentag struct {
+	name  string
+	start int
+	style string
+}
+
+// Append text, collapsing runs of whitespace into one space.
+func (builder *markupbuilder) write(text string) {
+	for _, char := range text {
+		if unicode.IsSpace(char) {
+			builder.spaced = builder.text.Len() > 0
+			continue
+		}
+		if builder.spaced {
+			builder.text.WriteByte(' ')
+			builder.spaced = false
+		}
+		builder.text.WriteRune(char)
+	}
+}
+
+// Open a tag at the current position.
+func (builder *markupbuilder) open(name string, style string) {
+	start := builder.text.Len()
+	if builder.spaced {
+		start++ // The pending space belongs before the span.
+	}
+	builder.opened = append(builder.opened, opentag{name, start, style})
+}
+
+// Close the most recent tag with the given name, and any opened after it.
+func (builder *markupbuilder) close(name string) {
+	for i := len(builder.opened) - 1; i >= 0; i-- {
+		if builder.opened[i].name != name {
+			continue
+		}
+		for len(builder.opened) > i {
+			builder.pop(builder.text.Len())
+		}
+		return
+	}
+}
+
+// Close the most recently opened tag at end.
+func (builder *markupbuilder) pop(end int) {
+	last := builder.opened[len(builder.opened)-1]
+	builder.opened = builder.opened[:len(builder.opened)-1]
+	if last.style != "" && end > last.start {
+		builder.spans = append(builder.spans, Span{last.start, end, last.style})
+	}
+}
+
+// Strip the HTML tags and entities of a Naver string, keeping emphasis as spans.
+// Whitespace is collapsed, and <br> becomes a space.
+func NormaliseMarkup(raw string) Markup {
+	builder := &markupbuilder{}
+	position := 0
+	for _, match := range tagpattern.FindAllStringSubmatchIndex(raw, -1) {
+		builder.write(html.UnescapeString(raw[position:match[0]]))
+		position = match[1]
+		closing := match[3] > match[2]
+		name := strings.ToLower(raw[match[4]:match[5]])
+		attributes := raw[match[6]:match[7]]
+		switch {
+		case name == "br" || name == "p" || name == "div" || name == "li":
+			builder.write(" ")
+		case closing:
+			builder.close(name)
+		case strings.HasSuffix(attributes, "/"):
+			// Self-closing tags have no content to emphasise.
+		default:
+			builder.open(name, tagstyle(name, attributes))
+		}
+	}
+	builder.write(html.UnescapeString(raw[position:]))
+	for len(builder.opened) > 0 {
+		builder.pop(builder.text.Len()) // Close unclosed tags at the end.
+	}
+
+	markup := Markup{Text: builder.text.String(), Spans: builder.spans}
+	sort.SliceStable(markup.Spans, func(i, j int) bool {
+		if markup.Spans[i].Start != markup.Spans[j].Start {
+			return markup.Spans[i].Start < markup.Spans[j].Start
+		}
+		return markup.Spans[i].End > markup.Spans[j].End
+	})
+	return markup
+}
+
+// Strip the HTML tags and entities of a Naver string.
+func StripMarkup(raw string) string {
+	return NormaliseMarkup(raw).Text
+}
+
+// Render a Markup in another format. escape is applied to every piece of
+// text, and wrap returns the opening and closing syntax of a style, e.g.
+// "*" and "*" for bold in Markdown.
+func (markup Markup) Render(escape func(string) string, wrap func(style string) (string, string)) string {
+	if escape == nil {
+		escape = func(text string) string { return text }
+	}
+	var rendered strings.Builder
+	opened := []Span{}
+	next := 0
+	// Emit text up to offset, then close and open the spans that end or start there.
+	for offset := 0; offset <= len(markup.Text); {
+		for len(opened) > 0 && opened[len(opened)-1].End <= offset {
+			_, closing := wrap(opened[len(opened)-1].Style)
+			rendered.WriteString(closing)
+			opened = opened[:len(opened)-1]
+		}
+		for next < len(markup.Spans) && markup.Spans[next].Start <= offset {
+			span := markup.Spans[next]
+			next++
+			if span.End <= offset || span.End > len(markup.Text) {
+				continue
+			}
+			opening, _ := wrap(span.Style)
+			rendered.WriteString(opening)
+			opened = append(opened, span)
+		}
+		if offset == len(markup.Text) {
+			break
+		}
+		// Advance to the next span boundary.
+		boundary := len(markup.Text)
+		if len(opened) > 0 && opened[len(opened)-1].End < boundary {
+			boundary = opened[len(opened)-1].End
+		}
+		if next < len(markup.Spans) && markup.Spans[next].Start < boundary {
+			boundary = markup.Spans[next].Start
+		}
+		for boundary < len(markup.Text) && !utf8.RuneStart(markup.Text[boundary]) {
+			boundary++
+		}
+		rendered.WriteString(escape(markup.Text[offset:boundary]))
+		offset = boundary
+	}
+	return rendered.String()
+}
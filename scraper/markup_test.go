@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripMarkup(t *testing.T) {
+	tests := map[string]string{
+		"love; affection":                                    "love; affection",
+		"<b>사랑</b>하는 사람":                                     "사랑하는 사람",
+		"a &lt;b&gt; tag &amp; more":                         "a <b> tag & more",
+		`<span class="u_word_dic" data-hook="tip">지금</span>`: "지금",
+		"  two<br>lines\n\t here ":                           "two lines here",
+		"1 < 2 and 3 > 2":                                    "1 < 2 and 3 > 2",
+		"&nbsp;&quot;quoted&quot;":                           `"quoted"`,
+	}
+	for raw, want := range tests {
+		got := StripMarkup(raw)
+		if got != want {
+			t.Errorf("StripMarkup(%q) = %q; want %q", raw, got, want)
+		}
+	}
+}
+
+func TestNormaliseMarkupSpans(t *testing.T) {
+	tests := map[string]Markup{
+		"<b>사랑</b>하다": {"사랑하다", []Span{{0, 6, StyleBold}}},
+		"I <strong><em>really</em> do</strong>": {"I really do", []Span{
+			{2, 11, StyleBold},
+			{2, 8, StyleItalic},
+		}},
+		`가족의 <span class="highlight">안녕</span>을 빌다.`: {"가족의 안녕을 빌다.", []Span{{10, 16, StyleHighlight}}},
+		"<b> </b>empty":   {"empty", nil},
+		"unclosed <u>end": {"unclosed end", []Span{{9, 12, StyleUnderline}}},
+	}
+	for raw, want := range tests {
+		got := NormaliseMarkup(raw)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("NormaliseMarkup(%q) = %+v; want %+v", raw, got, want)
+		}
+	}
+}
+
+func TestMarkupRender(t *testing.T) {
+	markup := NormaliseMarkup("I <strong><em>really</em> do</strong> <3 it")
+	wrap := func(style string) (string, string) {
+		if style == StyleBold {
+			return "*", "*"
+		}
+		return "_", "_"
+	}
+	escape := func(text string) string {
+		return strings.ReplaceAll(text, "<", "&lt;")
+	}
+	got := markup.Render(escape, wrap)
+	want := "I *_really_ do* &lt;3 it"
+	if got != want {
+		t.Errorf("Render() = %q; want %q", got, want)
+	}
+}
+
+func TestGetMeaningStripsMarkup(t *testing.T) {
+	meaningitem := map[string]interface{}{
+		"show_mean":        "<b>love</b> &amp; affection",
+		"description_json": `{"en":"The <i>feeling</i>.", "ko":"마음."}`,
+		"examples": []interface{}{
+			map[string]interface{}{"origin_example": "부모의 <strong>사랑</strong>."},
+		},
+	}
+	got, errmeaning := GetMeaning(meaningitem, 0)
+	want := "1.love & affection\nThe feeling.\n마음.\n|| 부모의 사랑."
+	if errmeaning != nil || got != want {
+		t.Errorf("GetMeaning() = %q, %v; want %q", got, errmeaning, want)
+	}
+}
@@ -21,6 +21,11 @@ func Buildsentence(prefix string, components []string) string {
 }
 
 func Buildmessage(dictinfo DictInfo) string {
+	return BuildmessageIn(DefaultLocale, dictinfo)
+}
+
+// Build a message with labels in a locale.
+func BuildmessageIn(locale string, dictinfo DictInfo) string {
 	// Return empty string if all fields are empty
 	if dictinfo.Topik == "" && dictinfo.Importance == "" && dictinfo.Title == "" &&
 		dictinfo.Hanja == "" && dictinfo.Endef == "" && dictinfo.Pronun == "" &&
@@ -32,10 +37,10 @@ func Buildmessage(dictinfo DictInfo) string {
 		Buildsentence("", []string{dictinfo.Topik, dictinfo.Importance}),
 		Buildsentence("", []string{dictinfo.Title, dictinfo.Hanja}),
 		Buildsentence("", []string{dictinfo.Endef}),
-		Buildsentence("----------\nPronunciation:\nroma ", []string{dictinfo.Pronun}),
-		"----------",
-		Buildsentence("", []string{dictinfo.Partspeech}),
-		Buildsentence("", []string{dictinfo.Meanings}),
+		Buildsentence("----------\n"+Translate(locale, LabelPronunciation)+"\n"+Translate(locale, LabelRomanisation)+" ", []string{dictinfo.Pronun}),
+	}
+	if dictinfo.Partspeech != "" || dictinfo.Meanings != "" { // No meanings, e.g. in compact messages.
+		parts = append(parts, "----------", dictinfo.Partspeech, dictinfo.Meanings)
 	}
 
 	filtered := make([]string, 0, len(parts))
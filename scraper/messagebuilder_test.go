@@ -86,3 +86,15 @@ func TestBuildmessageCompleteValid(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, result)
 	}
 }
+
+func TestBuildmessageNoMeaningsValid(t *testing.T) {
+	// Test input without a part of speech or meanings, e.g. a compact message
+	dictinfo := completedictinfo
+	dictinfo.Partspeech = ""
+	dictinfo.Meanings = ""
+	result := Buildmessage(dictinfo)
+	expected := "(TOPIK Elementary) ★★\n강아지 奮發\n1.puppy 2.small dog 3.young dog\n----------\nPronunciation:\nroma [gang-a-ji] [강아지]"
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
@@ -0,0 +1,465 @@
+package scraper
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// OfflineEntry is a dictionary entry stored in an OfflineIndex.
+type OfflineEntry struct {
+	Id            string
+	Headword      string
+	Hanja         string
+	Pronunciation string // In Hangul, e.g. 사랑.
+	PartOfSpeech  string // In Korean, e.g. 명사.
+	Level         string // Vocabulary level, one of 초급, 중급, 고급 or empty.
+	Senses        []OfflineSense
+}
+
+// OfflineSense is one meaning of an OfflineEntry.
+type OfflineSense struct {
+	Definition        string // In Korean.
+	EnglishLemma      string
+	EnglishDefinition string
+	Examples          []string
+}
+
+// OfflineIndex is a local dictionary, searchable by headword.
+type OfflineIndex struct {
+	Entries   map[string]OfflineEntry // Keyed by Id.
+	Headwords map[string][]string     // Ids of the entries of each headword, in dump order.
+}
+
+// Create an empty OfflineIndex.
+func NewOfflineIndex() *OfflineIndex {
+	return &OfflineIndex{
+		Entries:   map[string]OfflineEntry{},
+		Headwords: map[string][]string{},
+	}
+}
+
+// Add an entry to the index, replacing any entry with the same Id.
+func (index *OfflineIndex) Add(entry OfflineEntry) {
+	_, exists := index.Entries[entry.Id]
+	index.Entries[entry.Id] = entry
+	if !exists {
+		index.Headwords[entry.Headword] = append(index.Headwords[entry.Headword], entry.Id)
+	}
+}
+
+// Find the entries of a headword.
+func (index *OfflineIndex) Find(headword string) []OfflineEntry {
+	ids := index.Headwords[headword]
+	entries := make([]OfflineEntry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, index.Entries[id])
+	}
+	return entries
+}
+
+// Headwords in the index, sorted, e.g. to seed suggestions.
+func (index *OfflineIndex) HeadwordList() []string {
+	words := make([]string, 0, len(index.Headwords))
+	for word := range index.Headwords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Save the index to a JSON file.
+func (index *OfflineIndex) Save(path string) error {
+	file, errcreate := os.Create(path)
+	if errcreate != nil {
+		msg := fmt.Sprintf("cannot create offline index: %v", errcreate)
+		return errors.New(msg)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	errencode := json.NewEncoder(writer).Encode(index)
+	if errencode != nil {
+		msg := fmt.Sprintf("cannot encode offline index: %v", errencode)
+		return errors.New(msg)
+	}
+	return writer.Flush()
+}
+
+// Load an index saved by OfflineIndex.Save.
+func LoadOfflineIndex(path string) (*OfflineIndex, error) {
+	file, erropen := os.Open(path)
+	if erropen != nil {
+		msg := fmt.Sprintf("cannot open offline index: %v", erropen)
+		return nil, errors.New(msg)
+	}
+	defer file.Close()
+	index := NewOfflineIndex()
+	errdecode := json.NewDecoder(bufio.NewReader(file)).Decode(index)
+	if errdecode != nil {
+		msg := fmt.Sprintf("cannot decode offline index: %v", errdecode)
+		return nil, errors.New(msg)
+	}
+	return index, nil
+}
+
+// The National Institute of Korean Language dictionaries (e.g. 한국어기초사전)
+// are exported as LMF: every field is a <feat att="name" val="value"/>.
+type lmffeat struct {
+	Att string `xml:"att,attr" json:"att"`
+	Val string `xml:"val,attr" json:"val"`
+}
+
+// lmflist decodes JSON exports, where elements that occur once are
+// written as an object instead of an array.
+type lmflist[T any] []T
+
+func (list *lmflist[T]) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		var items []T
+		errdecode := json.Unmarshal(data, &items)
+		*list = items
+		return errdecode
+	}
+	var item T
+	errdecode := json.Unmarshal(data, &item)
+	*list = lmflist[T]{item}
+	return errdecode
+}
+
+type lmfgroup struct {
+	Feats lmflist[lmffeat] `xml:"feat" json:"feat"`
+}
+
+type lmfsense struct {
+	Feats       lmflist[lmffeat]  `xml:"feat" json:"feat"`
+	Equivalents lmflist[lmfgroup] `xml:"Equivalent" json:"Equivalent"`
+	Examples    lmflist[lmfgroup] `xml:"SenseExample" json:"SenseExample"`
+}
+
+type lmfentry struct {
+	Id        string            `xml:"val,attr" json:"val"`
+	Feats     lmflist[lmffeat]  `xml:"feat" json:"feat"`
+	Lemmas    lmflist[lmfgroup] `xml:"Lemma" json:"Lemma"`
+	WordForms lmflist[lmfgroup] `xml:"WordForm" json:"WordForm"`
+	Senses    lmflist[lmfsense] `xml:"Sense" json:"Sense"`
+}
+
+// Get the value of the first feat named att.
+func featvalue(feats []lmffeat, att string) string {
+	for _, feat := range feats {
+		if feat.Att == att {
+			return feat.Val
+		}
+	}
+	return ""
+}
+
+// Convert an LMF entry into an OfflineEntry.
+func (lmf lmfentry) offlineentry() (OfflineEntry, error) {
+	entry := OfflineEntry{
+		Id:           "krdict-" + lmf.Id,
+		Hanja:        featvalue(lmf.Feats, "origin"),
+		PartOfSpeech: featvalue(lmf.Feats, "partOfSpeech"),
+		Level:        featvalue(lmf.Feats, "vocabularyLevel"),
+	}
+	if lmf.Id == "" {
+		return entry, errors.New("cannot find id in LexicalEntry")
+	}
+	for _, lemma := range lmf.Lemmas {
+		if writtenform := featvalue(lemma.Feats, "writtenForm"); writtenform != "" {
+			entry.Headword = writtenform
+			break
+		}
+	}
+	if entry.Headword == "" {
+		return entry, errors.New("cannot find writtenForm in LexicalEntry " + lmf.Id)
+	}
+	for _, wordform := range lmf.WordForms {
+		if pronunciation := featvalue(wordform.Feats, "pronunciation"); pronunciation != "" {
+			entry.Pronunciation = pronunciation
+			break
+		}
+	}
+	if entry.Level == "없음" {
+		entry.Level = ""
+	}
+
+	for _, lmfsense := range lmf.Senses {
+		sense := OfflineSense{Definition: featvalue(lmfsense.Feats, "definition")}
+		for _, equivalent := range lmfsense.Equivalents {
+			if featvalue(equivalent.Feats, "language") == "영어" {
+				sense.EnglishLemma = featvalue(equivalent.Feats, "lemma")
+				sense.EnglishDefinition = featvalue(equivalent.Feats, "definition")
+				break
+			}
+		}
+		for _, example := range lmfsense.Examples {
+			if text := featvalue(example.Feats, "example"); text != "" {
+				sense.Examples = append(sense.Examples, text)
+			}
+		}
+		entry.Senses = append(entry.Senses, sense)
+	}
+	return entry, nil
+}
+
+// Import an LMF XML export (e.g. of 한국어기초사전) into an index.
+// Entries are streamed, so large dumps do not have to fit in memory twice.
+func ImportKrdictXML(reader io.Reader, index *OfflineIndex) (int, error) {
+	decoder := xml.NewDecoder(reader)
+	count := 0
+	for {
+		token, errtoken := decoder.Token()
+		if errtoken == io.EOF {
+			return count, nil
+		}
+		if errtoken != nil {
+			msg := fmt.Sprintf("cannot decode XML: %v", errtoken)
+			return count, errors.New(msg)
+		}
+		start, isstart := token.(xml.StartElement)
+		if !isstart || start.Name.Local != "LexicalEntry" {
+			continue
+		}
+		var lmf lmfentry
+		errdecode := decoder.DecodeElement(&lmf, &start)
+		if errdecode != nil {
+			msg := fmt.Sprintf("cannot decode LexicalEntry: %v", errdecode)
+			return count, errors.New(msg)
+		}
+		entry, errentry := lmf.offlineentry()
+		if errentry != nil {
+			return count, errentry
+		}
+		index.Add(entry)
+		count++
+	}
+}
+
+// Import an LMF JSON export (e.g. of 한국어기초사전) into an index.
+func ImportKrdictJSON(reader io.Reader, index *OfflineIndex) (int, error) {
+	var dump struct {
+		LexicalResource struct {
+			Lexicon struct {
+				LexicalEntry lmflist[lmfentry]
+			}
+		}
+	}
+	errdecode := json.NewDecoder(reader).Decode(&dump)
+	if errdecode != nil {
+		msg := fmt.Sprintf("cannot decode JSON: %v", errdecode)
+		return 0, errors.New(msg)
+	}
+	count := 0
+	for _, lmf := range dump.LexicalResource.Lexicon.LexicalEntry {
+		entry, errentry := lmf.offlineentry()
+		if errentry != nil {
+			return count, errentry
+		}
+		index.Add(entry)
+		count++
+	}
+	return count, nil
+}
+
+// Import a 한국어기초사전 export file, choosing the format by its extension.
+func ImportKrdictFile(path string, index *OfflineIndex) (int, error) {
+	file, erropen := os.Open(path)
+	if erropen != nil {
+		msg := fmt.Sprintf("cannot open dictionary dump: %v", erropen)
+		return 0, errors.New(msg)
+	}
+	defer file.Close()
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		return ImportKrdictJSON(bufio.NewReader(file), index)
+	}
+	return ImportKrdictXML(bufio.NewReader(file), index)
+}
+
+// OfflineProvider serves lookups from an OfflineIndex, in the same shape as
+// the Naver Dictionary API so that Scrape works unchanged.
+type OfflineProvider struct {
+	Index *OfflineIndex
+}
+
+func (provider *OfflineProvider) Name() string {
+	return "offline"
+}
+
+// Get Entry Information from the offline dictionary.
+func (provider *OfflineProvider) GetEntryInfo(searchterm string) (map[string]interface{}, error) {
+	// Equivalent to searchInfo.searchResultMap.searchResultListMap.WORD.items
+	entries := provider.Index.Find(searchterm)
+	if len(entries) == 0 {
+		msg := fmt.Sprintf("cannot find %q in offline dictionary", searchterm)
+		return nil, errors.New(msg)
+	}
+	items := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		items[i] = map[string]interface{}{
+			"entryId":    entry.Id,
+			"expEntry":   entry.Headword,
+			"expEntryId": entry.Id,
+		}
+	}
+	entryinfo := map[string]interface{}{
+		"searchResultMap": map[string]interface{}{
+			"searchResultListMap": map[string]interface{}{
+				"WORD": map[string]interface{}{
+					"items": items,
+					"total": float64(len(items)),
+				},
+			},
+		},
+	}
+	return entryinfo, nil
+}
+
+// Get Search Information from the offline dictionary.
+func (provider *OfflineProvider) GetSearchInfo(entryid string) (map[string]interface{}, error) {
+	entry, found := provider.Index.Entries[entryid]
+	if !found {
+		msg := fmt.Sprintf("cannot find entry %q in offline dictionary", entryid)
+		return nil, errors.New(msg)
+	}
+
+	// 한국어기초사전 marks learner vocabulary by level instead of TOPIK level and stars.
+	level, importance := "", 0.0
+	switch entry.Level {
+	case "초급":
+		level, importance = "1", 3.0
+	case "중급":
+		level, importance = "2", 2.0
+	case "고급":
+		importance = 1.0
+	}
+
+	lemmas := []string{}
+	means := make([]interface{}, len(entry.Senses))
+	for i, sense := range entry.Senses {
+		if sense.EnglishLemma != "" {
+			lemmas = append(lemmas, sense.EnglishLemma)
+		}
+		description, _ := json.Marshal(map[string]string{"en": sense.EnglishDefinition, "ko": sense.Definition})
+		examples := make([]interface{}, len(sense.Examples))
+		for j, example := range sense.Examples {
+			examples[j] = map[string]interface{}{"origin_example": example}
+		}
+		showmean := sense.EnglishLemma
+		if showmean == "" {
+			showmean = sense.Definition
+		}
+		means[i] = map[string]interface{}{
+			"part":             map[string]interface{}{"part_ko_name": entry.PartOfSpeech},
+			"show_mean":        showmean,
+			"description_json": string(description),
+			"examples":         examples,
+		}
+	}
+
+	pronunciation := entry.Pronunciation
+	if pronunciation == "" {
+		pronunciation = entry.Headword
+	}
+	searchinfo := map[string]interface{}{
+		"entry": map[string]interface{}{
+			"entry_id":         entry.Id,
+			"entry_level":      level,
+			"entry_importance": importance,
+			"primary_mean":     strings.Join(lemmas, "|||"),
+			"members": []interface{}{
+				map[string]interface{}{
+					"entry_name":      entry.Headword,
+					"origin_language": entry.Hanja,
+					"prons": []interface{}{
+						map[string]interface{}{"show_pron_symbol": Romanise(pronunciation)},
+						map[string]interface{}{"show_pron_symbol": pronunciation},
+					},
+				},
+			},
+			"means": means,
+		},
+	}
+	return searchinfo, nil
+}
+
+// FallbackProvider looks words up in Primary, and in Fallback when Primary fails.
+type FallbackProvider struct {
+	Primary  Provider
+	Fallback Provider
+}
+
+func (provider *FallbackProvider) Name() string {
+	return provider.Primary.Name() + "+" + provider.Fallback.Name()
+}
+
+// Get Entry Information from Primary, or from Fallback if Primary fails.
+func (provider *FallbackProvider) GetEntryInfo(searchterm string) (map[string]interface{}, error) {
+	entryinfo, errprimary := provider.Primary.GetEntryInfo(searchterm)
+	if errprimary == nil {
+		_, errentryid := GetEntryId(entryinfo)
+		if errentryid == nil {
+			return entryinfo, nil
+		}
+	}
+	return provider.Fallback.GetEntryInfo(searchterm)
+}
+
+// Get Search Information from Primary, or from Fallback if Primary fails.
+// Entry ids are not shared between providers, so whichever one issued
+// the id is the one that can answer. Ids of an offline Fallback go straight
+// to it, rather than to a Primary request that is bound to fail.
+func (provider *FallbackProvider) GetSearchInfo(entryid string) (map[string]interface{}, error) {
+	if offline, isoffline := provider.Fallback.(*OfflineProvider); isoffline {
+		if _, found := offline.Index.Entries[entryid]; found {
+			return offline.GetSearchInfo(entryid)
+		}
+	}
+	searchinfo, errprimary := provider.Primary.GetSearchInfo(entryid)
+	if errprimary == nil {
+		_, hasentry := searchinfo["entry"].(map[string]interface{})
+		if hasentry {
+			return searchinfo, nil
+		}
+	}
+	return provider.Fallback.GetSearchInfo(entryid)
+}
+
+// Serve lookups from the offline index saved at a path, as UseOfflineIndex.
+// An empty path leaves the providers as they are.
+func UseOfflineIndexFile(path string, mode string) error {
+	if path == "" {
+		return nil
+	}
+	index, errindex := LoadOfflineIndex(path)
+	if errindex != nil {
+		return errindex
+	}
+	return UseOfflineIndex(index, mode)
+}
+
+// Serve lookups from an offline index. The index is registered as the
+// `offline` dictionary, and becomes the DefaultProvider either in place of
+// Naver (mode "primary") or behind it (mode "fallback").
+func UseOfflineIndex(index *OfflineIndex, mode string) error {
+	offline := &OfflineProvider{index}
+	switch mode {
+	case "primary":
+		DefaultProvider = &FallbackProvider{offline, KoreanEnglish}
+	case "fallback":
+		DefaultProvider = &FallbackProvider{KoreanEnglish, offline}
+	case "":
+	default:
+		msg := fmt.Sprintf("unknown offline mode %q, want primary or fallback", mode)
+		return errors.New(msg)
+	}
+	RegisterProvider(offline)
+	AddHeadwords(index.HeadwordList())
+	return nil
+}
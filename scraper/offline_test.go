@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadsampleindex(t *testing.T) *OfflineIndex {
+	index := NewOfflineIndex()
+	count, error := ImportKrdictFile(filepath.Join("testdata", "krdict_sample.xml"), index)
+	if error != nil {
+		t.Fatalf("ImportKrdictFile() = %q; want no error", error)
+	}
+	if count != 2 {
+		t.Fatalf("ImportKrdictFile() = %d; want 2 entries", count)
+	}
+	return index
+}
+
+func TestImportKrdictXML(t *testing.T) {
+	index := loadsampleindex(t)
+	entries := index.Find("학교")
+	if len(entries) != 1 {
+		t.Fatalf("Find(%q) = %v; want 1 entry", "학교", entries)
+	}
+	entry := entries[0]
+	if entry.Id != "krdict-15642" || entry.Hanja != "學校" || entry.Pronunciation != "학꾜" || entry.Level != "중급" {
+		t.Errorf("Find(%q) = %+v; want 학교 entry", "학교", entry)
+	}
+	if len(entry.Senses) != 1 || entry.Senses[0].EnglishLemma != "school" || entry.Senses[0].Examples[0] != "학교에 가다." {
+		t.Errorf("Find(%q) senses = %+v; want school", "학교", entry.Senses)
+	}
+}
+
+func TestImportKrdictJSON(t *testing.T) {
+	// Elements that occur once are objects rather than arrays in JSON exports.
+	dump := `{"LexicalResource": {"Lexicon": {"LexicalEntry": {
+		"att": "id", "val": "1",
+		"feat": {"att": "partOfSpeech", "val": "명사"},
+		"Lemma": {"feat": {"att": "writtenForm", "val": "나무"}},
+		"Sense": [{"feat": {"att": "definition", "val": "줄기나 가지가 단단한 식물."},
+			"Equivalent": [{"feat": [{"att": "language", "val": "영어"}, {"att": "lemma", "val": "tree"}]}]}]
+	}}}}`
+	index := NewOfflineIndex()
+	count, error := ImportKrdictJSON(strings.NewReader(dump), index)
+	if error != nil {
+		t.Fatalf("ImportKrdictJSON() = %q; want no error", error)
+	}
+	entries := index.Find("나무")
+	if count != 1 || len(entries) != 1 || entries[0].Senses[0].EnglishLemma != "tree" {
+		t.Errorf("ImportKrdictJSON() = %d, %+v; want 나무 entry", count, entries)
+	}
+}
+
+func TestOfflineProviderGet(t *testing.T) {
+	provider := &OfflineProvider{loadsampleindex(t)}
+	got, error := GetFrom(provider, "사랑")
+	if error != nil {
+		t.Fatalf("GetFrom(offline, %q) = %q; want no error", "사랑", error)
+	}
+	want := DictInfo{
+		Topik:      "(TOPIK Elementary)",
+		Importance: "★★★",
+		Title:      "사랑",
+		Hanja:      "",
+		Endef:      "1.love 2.romance",
+		Pronun:     "[sarang] [사랑]",
+		Partspeech: "명사",
+		Meanings: "1.love\nThe feeling of caring for someone deeply.\n어떤 사람이나 존재를 몹시 아끼고 귀중히 여기는 마음.\n|| 부모의 사랑.\n\n" +
+			"2.romance\nThe feeling of a man and a woman longing for each other.\n남녀가 서로 그리워하고 좋아하는 마음.\n|| 두 사람은 사랑에 빠졌다.",
+
+		TopikLevel:      TopikElementary,
+		ImportanceLevel: 3,
+	}
+	if got != want {
+		t.Errorf("GetFrom(offline, %q) = %+v; want %+v", "사랑", got, want)
+	}
+}
+
+func TestOfflineProviderMissing(t *testing.T) {
+	provider := &OfflineProvider{loadsampleindex(t)}
+	_, error := GetFrom(provider, "없는말")
+	if error == nil {
+		t.Errorf("GetFrom(offline, %q) = nil; want error", "없는말")
+	}
+}
+
+// failingprovider is a Provider that is always unavailable.
+type failingprovider struct{}
+
+func (failingprovider) Name() string { return "failing" }
+func (failingprovider) GetEntryInfo(string) (map[string]interface{}, error) {
+	return nil, errors.New("unavailable")
+}
+func (failingprovider) GetSearchInfo(string) (map[string]interface{}, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestFallbackProvider(t *testing.T) {
+	provider := &FallbackProvider{failingprovider{}, &OfflineProvider{loadsampleindex(t)}}
+	got, error := GetFrom(provider, "학교")
+	if error != nil {
+		t.Fatalf("GetFrom(fallback, %q) = %q; want no error", "학교", error)
+	}
+	if got.Title != "학교" || got.Pronun != "[hakkkyo] [학꾜]" || got.Topik != "(TOPIK Intermediate)" {
+		t.Errorf("GetFrom(fallback, %q) = %+v; want 학교", "학교", got)
+	}
+}
+
+func TestOfflineIndexSaveLoad(t *testing.T) {
+	index := loadsampleindex(t)
+	path := filepath.Join(t.TempDir(), "index.json")
+	if error := index.Save(path); error != nil {
+		t.Fatalf("Save(%q) = %q; want no error", path, error)
+	}
+	loaded, error := LoadOfflineIndex(path)
+	if error != nil {
+		t.Fatalf("LoadOfflineIndex(%q) = %q; want no error", path, error)
+	}
+	if len(loaded.Find("사랑")) != 1 || len(loaded.Entries) != 2 {
+		t.Errorf("LoadOfflineIndex(%q) = %+v; want the saved index", path, loaded)
+	}
+}
+
+func TestUseOfflineIndexFile(t *testing.T) {
+	if error := UseOfflineIndexFile("", "primary"); error != nil {
+		t.Errorf("UseOfflineIndexFile(%q) = %q; want no error", "", error)
+	}
+	path := filepath.Join(t.TempDir(), "missing.json")
+	if error := UseOfflineIndexFile(path, "primary"); error == nil {
+		t.Errorf("UseOfflineIndexFile(%q) = nil; want error", path)
+	}
+
+	saved := DefaultProvider
+	defer func() { DefaultProvider = saved }()
+	path = filepath.Join(t.TempDir(), "index.json")
+	loadsampleindex(t).Save(path)
+	if error := UseOfflineIndexFile(path, "primary"); error != nil {
+		t.Fatalf("UseOfflineIndexFile(%q) = %q; want no error", path, error)
+	}
+	got, error := GetEntry("학교")
+	if error != nil || got.Title != "학교" {
+		t.Errorf("GetEntry(%q) = %+v, %v; want 학교 from the offline index", "학교", got, error)
+	}
+}
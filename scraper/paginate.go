@@ -0,0 +1,285 @@
+package scraper
+
+import (
+	"sort"
+	"strings"
+)
+
+// Maximum length of a Telegram message, in UTF-16 code units.
+const TelegramMessageLimit = 4096
+
+// Length of a message in UTF-16 code units, as counted by Telegram.
+func MessageLength(message string) int {
+	length := 0
+	for _, char := range message {
+		if char > 0xFFFF {
+			length += 2
+		} else {
+			length++
+		}
+	}
+	return length
+}
+
+// Render an Entry into pages of at most limit UTF-16 code units, e.g.
+// TelegramMessageLimit. Pages are split between senses, so that each page
+// is a complete message of the renderer; the first page has the whole
+// header, and later pages only the title. A sense too long for a page on
+// its own is split by SplitMessage. A limit of zero or less returns one page.
+func Paginate(renderer Renderer, entry Entry, limit int) []string {
+	full := renderer.Render(entry)
+	if limit <= 0 || MessageLength(full) <= limit {
+		return []string{full}
+	}
+
+	// Page through the senses in the order they are rendered in.
+	senses := map[int]Sense{}
+	for _, sense := range entry.Senses {
+		senses[sense.Number] = sense
+	}
+	groups := entry.Groups
+	if groups == nil {
+		groups = GroupSenses(entry.Senses)
+	}
+	ordered := make([]Sense, 0, len(entry.Senses))
+	for _, group := range groups {
+		for _, number := range group.Senses {
+			ordered = append(ordered, senses[number])
+		}
+	}
+
+	pages := []string{}
+	render := func(pagesenses []Sense) string {
+		page := Entry{Title: entry.Title}
+		if len(pages) == 0 {
+			page = entry
+		}
+		page.Senses = pagesenses
+		page.Groups = GroupSenses(pagesenses)
+		return renderer.Render(page)
+	}
+	current := []Sense{}
+	for _, sense := range ordered {
+		candidate := append(append([]Sense{}, current...), sense)
+		if MessageLength(render(candidate)) <= limit {
+			current = candidate
+			continue
+		}
+		if len(current) > 0 {
+			pages = append(pages, render(current))
+			current = []Sense{sense}
+			if MessageLength(render(current)) <= limit {
+				continue
+			}
+		}
+		pages = append(pages, SplitMessage(formatof(renderer), render([]Sense{sense}), limit)...)
+		current = []Sense{}
+	}
+	if len(current) > 0 || len(pages) == 0 {
+		pages = append(pages, render(current))
+	}
+	return pages
+}
+
+// Get the Format a Renderer styles text with, or nil for plain text and
+// renderers without one.
+func formatof(renderer Renderer) *Format {
+	switch renderer := renderer.(type) {
+	case *Format:
+		return renderer
+	case *TemplateRenderer:
+		return renderer.Format
+	case configured:
+		return formatof(renderer.Renderer)
+	}
+	return nil
+}
+
+// Split a message into parts of at most limit UTF-16 code units, preferring
+// line breaks, then spaces. Parts are never cut inside an HTML tag or entity
+// or after a backslash escape. Styles of format spanning a cut are closed at
+// the end of the part and reopened at the start of the next; a nil format
+// has no styles. When limit is too short to reopen them, the rest of the
+// message is split without its styles, so that no part is left unbalanced.
+func SplitMessage(format *Format, message string, limit int) []string {
+	parts := []string{}
+	for limit > 0 && MessageLength(message) > limit {
+		// Leave room for closing the styles open at the cut.
+		var part, closing, opening string
+		cut := 0
+		for reserve := 0; reserve < limit; {
+			cut = splitpoint(message, limit-reserve)
+			part = strings.TrimRight(message[:cut], "\n ")
+			closing, opening = wrapopen(format, openstyles(format, part))
+			if MessageLength(part+closing) <= limit || MessageLength(closing) <= reserve {
+				break
+			}
+			reserve = MessageLength(closing)
+		}
+		if len(opening) >= cut {
+			// Too short a limit to reopen the styles and still make progress,
+			// so the rest of the message is split as plain text.
+			message = stripstyles(format, message)
+			cut = splitpoint(message, limit)
+			part = strings.TrimRight(message[:cut], "\n ")
+			closing, opening = "", ""
+		}
+		parts = append(parts, part+closing)
+		message = opening + strings.TrimLeft(message[cut:], "\n ")
+	}
+	if message != "" || len(parts) == 0 {
+		parts = append(parts, message)
+	}
+	return parts
+}
+
+// Remove the syntax of the styles of format from text, keeping escapes and
+// entities, so that it is valid with any cut.
+func stripstyles(format *Format, text string) string {
+	if format == nil {
+		return text
+	}
+	styles := formatstyles(format)
+	var stripped strings.Builder
+	for offset := 0; offset < len(text); {
+		if text[offset] == '\\' && offset+1 < len(text) {
+			stripped.WriteString(text[offset : offset+2])
+			offset += 2
+			continue
+		}
+		matched := 0
+		for _, style := range styles {
+			opening, closing := format.Wrap(style)
+			for _, syntax := range []string{opening, closing} {
+				if matched == 0 && strings.HasPrefix(text[offset:], syntax) {
+					matched = len(syntax)
+				}
+			}
+		}
+		if matched == 0 {
+			stripped.WriteByte(text[offset])
+			matched = 1
+		}
+		offset += matched
+	}
+	return stripped.String()
+}
+
+// Styles of a Format, with the longest syntax first, so that e.g. __ is
+// read as an underline rather than two italics in MarkdownV2.
+func formatstyles(format *Format) []string {
+	styles := []string{}
+	for _, style := range []string{StyleBold, StyleItalic, StyleUnderline, StyleHighlight} {
+		if open, _ := format.Wrap(style); open != "" {
+			styles = append(styles, style)
+		}
+	}
+	sort.SliceStable(styles, func(i, j int) bool {
+		openi, _ := format.Wrap(styles[i])
+		openj, _ := format.Wrap(styles[j])
+		return len(openi) > len(openj)
+	})
+	return styles
+}
+
+// Get the styles of format left open at the end of text, in the order they
+// were opened.
+func openstyles(format *Format, text string) []string {
+	open := []string{}
+	if format == nil {
+		return open
+	}
+	styles := formatstyles(format)
+	for offset := 0; offset < len(text); {
+		if text[offset] == '\\' {
+			offset += 2 // Escaped in MarkdownV2.
+			continue
+		}
+		matched := 0
+		for _, style := range styles {
+			opening, closing := format.Wrap(style)
+			index := -1
+			for i := range open {
+				if open[i] == style {
+					index = i
+				}
+			}
+			if index >= 0 && strings.HasPrefix(text[offset:], closing) {
+				open = append(open[:index], open[index+1:]...)
+				matched = len(closing)
+				break
+			}
+			if index < 0 && strings.HasPrefix(text[offset:], opening) {
+				open = append(open, style)
+				matched = len(opening)
+				break
+			}
+		}
+		if matched == 0 {
+			matched = 1
+		}
+		offset += matched
+	}
+	return open
+}
+
+// Get the syntax closing open styles, innermost first, and reopening them.
+func wrapopen(format *Format, open []string) (string, string) {
+	closing, opening := "", ""
+	for _, style := range open {
+		before, after := format.Wrap(style)
+		opening += before
+		closing = after + closing
+	}
+	return closing, opening
+}
+
+// Find the byte offset to cut a message at, within limit UTF-16 code units
+// when possible.
+func splitpoint(message string, limit int) int {
+	length := 0
+	lastnewline, lastspace, lastsafe := 0, 0, 0
+	intag, inentity, escaped := false, false, false
+	for offset, char := range message {
+		charlength := 1
+		if char > 0xFFFF {
+			charlength = 2
+		}
+		if !intag && !inentity && !escaped && offset > 0 {
+			if length > limit {
+				return offset // Past the limit, as there was no safe point before it.
+			}
+			lastsafe = offset
+			switch char {
+			case '\n':
+				lastnewline = offset
+			case ' ':
+				lastspace = offset
+			}
+		}
+		if length+charlength > limit && lastsafe > 0 {
+			break
+		}
+		length += charlength
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == '<':
+			intag = true
+		case char == '>':
+			intag = false
+		case char == '&':
+			inentity = true
+		case char == ';' || char == ' ' || char == '\n':
+			inentity = false
+		}
+	}
+	for _, cut := range []int{lastnewline, lastspace, lastsafe} {
+		if cut > 0 {
+			return cut
+		}
+	}
+	return len(message)
+}
@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// An entry with many long senses.
+func longentry(count int) Entry {
+	entry := Entry{Title: "가다", Glosses: []string{"go"}}
+	for i := 1; i <= count; i++ {
+		entry.Senses = append(entry.Senses, Sense{
+			Number:       i,
+			PartOfSpeech: "동사",
+			Gloss:        fmt.Sprintf("sense %d", i),
+			Definition:   strings.Repeat("To move to another place. ", 8),
+			Examples:     []Markup{{Text: "학교에 가다.", Spans: []Span{{10, 16, StyleBold}}}},
+		})
+	}
+	entry.Groups = GroupSenses(entry.Senses)
+	return entry
+}
+
+func TestMessageLength(t *testing.T) {
+	tests := map[string]int{
+		"hello": 5,
+		"안녕":    2,
+		"😀":     2, // Outside the Basic Multilingual Plane.
+	}
+	for message, want := range tests {
+		got := MessageLength(message)
+		if got != want {
+			t.Errorf("MessageLength(%q) = %d; want %d", message, got, want)
+		}
+	}
+}
+
+func TestPaginateShort(t *testing.T) {
+	pages := Paginate(MarkdownV2, renderentry, TelegramMessageLimit)
+	if len(pages) != 1 || pages[0] != MarkdownV2.Render(renderentry) {
+		t.Errorf("Paginate(short) = %q; want one page", pages)
+	}
+}
+
+func TestPaginateSenses(t *testing.T) {
+	entry := longentry(30)
+	for _, renderer := range []Renderer{PlainText, MarkdownV2, TelegramHTML} {
+		pages := Paginate(renderer, entry, 1000)
+		if len(pages) < 2 {
+			t.Errorf("Paginate(%s) = %d pages; want several", renderer.Name(), len(pages))
+		}
+		found := 0
+		for i, page := range pages {
+			if MessageLength(page) > 1000 {
+				t.Errorf("Paginate(%s) page %d has length %d; want at most 1000", renderer.Name(), i, MessageLength(page))
+			}
+			found += strings.Count(page, "sense ")
+		}
+		if found != 30 {
+			t.Errorf("Paginate(%s) has %d senses; want 30", renderer.Name(), found)
+		}
+	}
+}
+
+func TestPaginateKeepsMarkup(t *testing.T) {
+	// Every page is a complete HTML message, so tags are balanced.
+	for i, page := range Paginate(TelegramHTML, longentry(30), 1000) {
+		if strings.Count(page, "<b>") != strings.Count(page, "</b>") {
+			t.Errorf("Paginate(html) page %d = %q; want balanced tags", i, page)
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		limit   int
+		want    []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"one two\nthree four", 12, []string{"one two", "three four"}},
+		{"one two three", 9, []string{"one two", "three"}},
+		{"a &amp; b", 4, []string{"a", "&amp;", "b"}},
+		{"ab\\.cd", 3, []string{"ab", "\\.c", "d"}},
+	}
+	for _, test := range tests {
+		got := SplitMessage(nil, test.message, test.limit)
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("SplitMessage(%q, %d) = %q; want %q", test.message, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestSplitMessageStyles(t *testing.T) {
+	tests := []struct {
+		format  *Format
+		message string
+		limit   int
+		want    []string
+	}{
+		{TelegramHTML, "<b>one two three</b> four", 17, []string{"<b>one two</b>", "<b>three</b> four"}},
+		{TelegramHTML, "<i>a <u>b c</u> d</i>", 18, []string{"<i>a <u>b</u></i>", "<i><u>c</u> d</i>"}},
+		{MarkdownV2, "*one two three* four", 12, []string{"*one two*", "*three* four"}},
+		{MarkdownV2, "__one \\_two__", 10, []string{"__one__", "__\\_two__"}},
+		// Too short to reopen, so the styles are dropped.
+		{TelegramHTML, "<b>abcdef</b>", 5, []string{"abcde", "f"}},
+		{TelegramHTML, "a <b>b &amp; c</b>", 6, []string{"a", "b", "&amp;", "c"}},
+		{MarkdownV2, "*ab\\*cd*", 2, []string{"ab", "\\*", "cd"}},
+	}
+	for _, test := range tests {
+		got := SplitMessage(test.format, test.message, test.limit)
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("SplitMessage(%s, %q, %d) = %q; want %q", test.format.Name(), test.message, test.limit, got, test.want)
+		}
+		for _, part := range got {
+			if open := openstyles(test.format, part); len(open) > 0 {
+				t.Errorf("SplitMessage(%s, %q, %d) part %q leaves %v open", test.format.Name(), test.message, test.limit, part, open)
+			}
+			if strings.Count(part, "<") != 2*strings.Count(part, "</") {
+				t.Errorf("SplitMessage(%s, %q, %d) part %q has unbalanced tags", test.format.Name(), test.message, test.limit, part)
+			}
+		}
+	}
+}
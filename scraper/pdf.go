@@ -0,0 +1,423 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// A minimal writer of PDF documents, see ISO 32000-1. It writes a whole
+// document at once, as needed for vocabulary sheets, with text in subsets
+// of embedded TrueType fonts.
+
+// pdfwriter lays out the objects of a document, numbered from 1.
+type pdfwriter struct {
+	objects [][]byte
+}
+
+// Reserve a number for an object that is set later, e.g. the parent of
+// pages that refer to it.
+func (writer *pdfwriter) reserve() int {
+	writer.objects = append(writer.objects, nil)
+	return len(writer.objects)
+}
+
+func (writer *pdfwriter) set(number int, object string) {
+	writer.objects[number-1] = []byte(object)
+}
+
+func (writer *pdfwriter) add(object string) int {
+	number := writer.reserve()
+	writer.set(number, object)
+	return number
+}
+
+// Add a stream compressed with Flate. entries are added to its dictionary.
+func (writer *pdfwriter) stream(entries string, data []byte) int {
+	var compressed bytes.Buffer
+	zlibwriter, _ := zlib.NewWriterLevel(&compressed, zlib.BestCompression)
+	zlibwriter.Write(data)
+	zlibwriter.Close()
+	header := fmt.Sprintf("<< %s /Length %d /Filter /FlateDecode >>\nstream\n", entries, compressed.Len())
+	number := writer.reserve()
+	writer.objects[number-1] = append(append([]byte(header), compressed.Bytes()...), "\nendstream"...)
+	return number
+}
+
+// Write the document with its catalog and information dictionary.
+func (writer *pdfwriter) write(output io.Writer, catalog int, info int) error {
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(writer.objects))
+	for i, object := range writer.objects {
+		if object == nil {
+			msg := fmt.Sprintf("PDF object %d is reserved but not set", i+1)
+			return errors.New(msg)
+		}
+		offsets[i] = document.Len()
+		fmt.Fprintf(&document, "%d 0 obj\n", i+1)
+		document.Write(object)
+		document.WriteString("\nendobj\n")
+	}
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(writer.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(writer.objects)+1, catalog, info, xref)
+	_, errwrite := output.Write(document.Bytes())
+	return errwrite
+}
+
+// A text string, in UTF-16 with a byte order mark so that any language
+// can be used, e.g. in the title of a document.
+func pdfstring(text string) string {
+	var encoded strings.Builder
+	encoded.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&encoded, "%04X", unit)
+	}
+	encoded.WriteString(">")
+	return encoded.String()
+}
+
+// A TrueType font that can be embedded in a document.
+type pdffontfile struct {
+	name   string // PostScript name.
+	data   []byte
+	parsed *sfnt.Font
+}
+
+// A TrueType font embedded in a document, and the glyphs used from it, to
+// embed only those.
+type pdfface struct {
+	name   string // PostScript name.
+	data   []byte
+	parsed *sfnt.Font
+	glyphs map[sfnt.GlyphIndex]rune
+	widths map[sfnt.GlyphIndex]int // In thousandths of the font size.
+}
+
+// pdftext measures and shows text in embedded TrueType fonts, trying each
+// in turn. Fonts are named F1, F2, and so on, in page resources.
+type pdftext struct {
+	faces  []*pdfface
+	buffer sfnt.Buffer
+}
+
+// A pdftext of a PostScript name and TrueType data for each font.
+func newpdftext(fonts ...pdffontfile) *pdftext {
+	text := &pdftext{}
+	for _, file := range fonts {
+		text.faces = append(text.faces, &pdfface{name: file.name, data: file.data, parsed: file.parsed, glyphs: map[sfnt.GlyphIndex]rune{}, widths: map[sfnt.GlyphIndex]int{}})
+	}
+	return text
+}
+
+// Face, glyph and width of a character. Characters that no font has are
+// shown as "?", or as the missing glyph of the first font if none has "?".
+func (text *pdftext) glyph(char rune) (int, sfnt.GlyphIndex, int) {
+	for number, face := range text.faces {
+		index, errindex := face.parsed.GlyphIndex(&text.buffer, char)
+		if errindex != nil || index == 0 {
+			continue
+		}
+		width, found := face.widths[index]
+		if !found {
+			advance, _ := face.parsed.GlyphAdvance(&text.buffer, index, fixed.I(1000), font.HintingNone)
+			width = advance.Round()
+			face.widths[index] = width
+		}
+		return number, index, width
+	}
+	if char != '?' {
+		return text.glyph('?')
+	}
+	return 0, 0, 1000
+}
+
+// Width of text at a size, in points.
+func (text *pdftext) measure(line string, size float64) float64 {
+	width := 0
+	for _, char := range line {
+		_, _, charwidth := text.glyph(char)
+		width += charwidth
+	}
+	return float64(width) * size / 1000
+}
+
+// Content operators that show text at a size, starting at x, y.
+func (text *pdftext) show(line string, size float64, x float64, y float64) string {
+	if line == "" {
+		return ""
+	}
+	var operators strings.Builder
+	fmt.Fprintf(&operators, "BT %.2f %.2f Td ", x, y)
+	current := ""
+	for _, char := range line {
+		number, index, _ := text.glyph(char)
+		name := fmt.Sprintf("F%d", number+1)
+		if name != current {
+			if current != "" {
+				operators.WriteString("> Tj ")
+			}
+			fmt.Fprintf(&operators, "/%s %.2f Tf <", name, size)
+			current = name
+		}
+		face := text.faces[number]
+		if _, found := face.glyphs[index]; !found {
+			face.glyphs[index] = char
+		}
+		fmt.Fprintf(&operators, "%04X", index)
+	}
+	operators.WriteString("> Tj ET\n")
+	return operators.String()
+}
+
+// Add the fonts used so far to a document. Returns the font resources of
+// pages.
+func (text *pdftext) embed(writer *pdfwriter) (string, error) {
+	resources := []string{}
+	for number, face := range text.faces {
+		if len(face.glyphs) == 0 {
+			continue
+		}
+		embedded, errembed := face.embed(writer, &text.buffer)
+		if errembed != nil {
+			return "", errembed
+		}
+		resources = append(resources, fmt.Sprintf("/F%d %d 0 R", number+1, embedded))
+	}
+	return "<< " + strings.Join(resources, " ") + " >>", nil
+}
+
+// Add a subset of a face to a document, as a Type 0 font. Returns its
+// object number.
+func (face *pdfface) embed(writer *pdfwriter, buffer *sfnt.Buffer) (int, error) {
+	keep := map[sfnt.GlyphIndex]bool{}
+	for index := range face.glyphs {
+		keep[index] = true
+	}
+	subset, errsubset := subsettruetype(face.data, keep)
+	if errsubset != nil {
+		return 0, errsubset
+	}
+
+	// A subset is named with a tag of six capital letters, see 9.6.4.
+	indexes := make([]int, 0, len(face.glyphs))
+	for index := range face.glyphs {
+		indexes = append(indexes, int(index))
+	}
+	sort.Ints(indexes)
+	hash := fnv.New32a()
+	fmt.Fprint(hash, face.name, indexes)
+	tag := []byte{}
+	for sum := hash.Sum32(); len(tag) < 6; sum /= 26 {
+		tag = append(tag, byte('A'+sum%26))
+	}
+	name := string(tag) + "+" + face.name
+
+	var widths strings.Builder
+	for _, index := range indexes {
+		fmt.Fprintf(&widths, "%d [%d] ", index, face.widths[sfnt.GlyphIndex(index)])
+	}
+	bounds, _ := face.parsed.Bounds(buffer, fixed.I(1000), font.HintingNone)
+	metrics, _ := face.parsed.Metrics(buffer, fixed.I(1000), font.HintingNone)
+
+	fontfile := writer.stream(fmt.Sprintf("/Length1 %d", len(subset)), subset)
+	descriptor := writer.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, bounds.Min.X.Round(), -bounds.Max.Y.Round(), bounds.Max.X.Round(), -bounds.Min.Y.Round(), metrics.Ascent.Round(), -metrics.Descent.Round(), metrics.CapHeight.Round(), fontfile))
+	cidfont := writer.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>",
+		name, descriptor, strings.TrimSpace(widths.String())))
+	tounicode := writer.stream("", face.tounicode(indexes))
+	return writer.add(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", name, cidfont, tounicode)), nil
+}
+
+// A CMap from the glyphs of a face back to characters, so that text can be
+// copied and searched.
+func (face *pdfface) tounicode(indexes []int) []byte {
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(indexes); start += 100 {
+		block := indexes[start:min(start+100, len(indexes))]
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(block))
+		for _, index := range block {
+			fmt.Fprintf(&cmap, "<%04X> <", index)
+			for _, unit := range utf16.Encode([]rune{face.glyphs[sfnt.GlyphIndex(index)]}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(cmap.String())
+}
+
+// Tables of a TrueType font kept in a subset: those needed by PDF readers,
+// and the small naming and mapping tables that font parsers expect.
+var truetypetables = []string{"OS/2", "cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "post", "prep"}
+
+// Subset a TrueType font to some glyphs, and the glyphs they are composed
+// of. Other glyphs are left empty, so that glyph indexes are unchanged.
+func subsettruetype(data []byte, keep map[sfnt.GlyphIndex]bool) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("Cannot subset a font of less than 12 bytes")
+	}
+	tables := map[string][]byte{}
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		if len(data) < 12+16*(i+1) {
+			return nil, errors.New("Cannot read the table directory of the font")
+		}
+		record := data[12+16*i:]
+		offset, length := int(binary.BigEndian.Uint32(record[8:])), int(binary.BigEndian.Uint32(record[12:]))
+		if offset+length > len(data) {
+			msg := fmt.Sprintf("Table %q of the font is out of bounds", record[:4])
+			return nil, errors.New(msg)
+		}
+		tables[string(record[:4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"glyf", "head", "loca", "maxp"} {
+		if _, found := tables[tag]; !found {
+			msg := fmt.Sprintf("Cannot find table %q of a TrueType font", tag)
+			return nil, errors.New(msg)
+		}
+	}
+
+	glyf, loca, head := tables["glyf"], tables["loca"], tables["head"]
+	numglyphs := int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	longloca := binary.BigEndian.Uint16(head[50:]) == 1
+	glyph := func(index int) []byte {
+		var start, end int
+		if longloca && len(loca) >= 4*index+8 {
+			start, end = int(binary.BigEndian.Uint32(loca[4*index:])), int(binary.BigEndian.Uint32(loca[4*index+4:]))
+		} else if !longloca && len(loca) >= 2*index+4 {
+			start, end = 2*int(binary.BigEndian.Uint16(loca[2*index:])), 2*int(binary.BigEndian.Uint16(loca[2*index+2:]))
+		}
+		if start > end || end > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	// Keep .notdef and the components of composite glyphs.
+	kept := map[int]bool{0: true}
+	queue := []int{0}
+	for index := range keep {
+		queue = append(queue, int(index))
+	}
+	for len(queue) > 0 {
+		index := queue[0]
+		queue = queue[1:]
+		if index >= numglyphs {
+			continue
+		}
+		kept[index] = true
+		outline := glyph(index)
+		if len(outline) < 10 || int16(binary.BigEndian.Uint16(outline)) >= 0 {
+			continue
+		}
+		for offset := 10; offset+4 <= len(outline); {
+			flags := binary.BigEndian.Uint16(outline[offset:])
+			component := int(binary.BigEndian.Uint16(outline[offset+2:]))
+			if !kept[component] {
+				queue = append(queue, component)
+			}
+			offset += 4 + 2
+			if flags&0x0001 != 0 { // ARG_1_AND_2_ARE_WORDS
+				offset += 2
+			}
+			switch {
+			case flags&0x0008 != 0: // WE_HAVE_A_SCALE
+				offset += 2
+			case flags&0x0040 != 0: // WE_HAVE_AN_X_AND_Y_SCALE
+				offset += 4
+			case flags&0x0080 != 0: // WE_HAVE_A_TWO_BY_TWO
+				offset += 8
+			}
+			if flags&0x0020 == 0 { // MORE_COMPONENTS
+				break
+			}
+		}
+	}
+
+	subsetglyf := []byte{}
+	subsetloca := make([]byte, 0, 4*(numglyphs+1))
+	for index := 0; index < numglyphs; index++ {
+		subsetloca = binary.BigEndian.AppendUint32(subsetloca, uint32(len(subsetglyf)))
+		if kept[index] {
+			subsetglyf = append(subsetglyf, glyph(index)...)
+			for len(subsetglyf)%4 != 0 {
+				subsetglyf = append(subsetglyf, 0)
+			}
+		}
+	}
+	subsetloca = binary.BigEndian.AppendUint32(subsetloca, uint32(len(subsetglyf)))
+	subsethead := append([]byte{}, head...)
+	binary.BigEndian.PutUint32(subsethead[8:], 0)  // checkSumAdjustment
+	binary.BigEndian.PutUint16(subsethead[50:], 1) // indexToLocFormat
+	tables["glyf"], tables["loca"], tables["head"] = subsetglyf, subsetloca, subsethead
+
+	tags := []string{}
+	for _, tag := range truetypetables {
+		if _, found := tables[tag]; found {
+			tags = append(tags, tag)
+		}
+	}
+	selector := 0
+	for 2<<selector <= len(tags) {
+		selector++
+	}
+	subset := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	subset = binary.BigEndian.AppendUint16(subset, uint16(len(tags)))
+	subset = binary.BigEndian.AppendUint16(subset, uint16(16<<selector))
+	subset = binary.BigEndian.AppendUint16(subset, uint16(selector))
+	subset = binary.BigEndian.AppendUint16(subset, uint16(16*len(tags)-16<<selector))
+	body := []byte{}
+	headoffset := 0
+	for _, tag := range tags {
+		table := tables[tag]
+		offset := 12 + 16*len(tags) + len(body)
+		if tag == "head" {
+			headoffset = offset
+		}
+		subset = append(subset, tag...)
+		subset = binary.BigEndian.AppendUint32(subset, truetypechecksum(table))
+		subset = binary.BigEndian.AppendUint32(subset, uint32(offset))
+		subset = binary.BigEndian.AppendUint32(subset, uint32(len(table)))
+		body = append(body, table...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	subset = append(subset, body...)
+	binary.BigEndian.PutUint32(subset[headoffset+8:], 0xB1B0AFBA-truetypechecksum(subset))
+	return subset, nil
+}
+
+// Sum of the big-endian 32-bit words of a table, padded with zeros.
+func truetypechecksum(table []byte) uint32 {
+	sum := uint32(0)
+	for i := 0; i < len(table); i += 4 {
+		word := [4]byte{}
+		copy(word[:], table[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,213 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Provider is a dictionary that search terms can be looked up in.
+// Responses follow the shape of the Naver Dictionary API, so that they can be
+// passed to GetEntryId and Scrape.
+type Provider interface {
+	Name() string // Identifies the provider, e.g. in the `dict=` REST parameter.
+	GetEntryInfo(searchterm string) (map[string]interface{}, error)
+	GetSearchInfo(entryid string) (map[string]interface{}, error)
+}
+
+// NaverProvider looks words up in one of Naver's dictionaries.
+type NaverProvider struct {
+	ProviderName string  // Name of the provider, e.g. koen.
+	Code         string  // Dictionary code used in the API paths, e.g. koen or jako.
+	Hostname     string  // e.g. https://korean.dict.naver.com
+	Referer      string  // Naver only returns JSON when the Referer is its own dictionary page.
+	Client       *Client // Nil uses the DefaultClient.
+}
+
+// Naver Korean-English Dictionary.
+var KoreanEnglish = &NaverProvider{
+	ProviderName: "koen",
+	Code:         "koen",
+	Hostname:     "https://korean.dict.naver.com",
+	Referer:      "https://korean.dict.naver.com/koendict/",
+}
+
+// Naver Korean-Korean Dictionary.
+var KoreanKorean = &NaverProvider{
+	ProviderName: "kodict",
+	Code:         "koko",
+	Hostname:     "https://ko.dict.naver.com",
+	Referer:      "https://ko.dict.naver.com/",
+}
+
+// Naver Korean-Japanese Dictionary.
+var KoreanJapanese = &NaverProvider{
+	ProviderName: "koja",
+	Code:         "jako",
+	Hostname:     "https://ja.dict.naver.com",
+	Referer:      "https://ja.dict.naver.com/",
+}
+
+// Naver Korean-Chinese Dictionary.
+var KoreanChinese = &NaverProvider{
+	ProviderName: "kozh",
+	Code:         "zhko",
+	Hostname:     "https://zh.dict.naver.com",
+	Referer:      "https://zh.dict.naver.com/",
+}
+
+// Provider used when none is requested.
+var DefaultProvider Provider = KoreanEnglish
+
+// providers stores every provider selectable by name.
+var providers = struct {
+	sync.RWMutex
+	byname map[string]Provider
+}{
+	byname: map[string]Provider{},
+}
+
+func init() {
+	RegisterProvider(KoreanEnglish)
+	RegisterProvider(KoreanKorean)
+	RegisterProvider(KoreanJapanese)
+	RegisterProvider(KoreanChinese)
+}
+
+// Make a Provider selectable by its name, replacing any provider of the same name.
+func RegisterProvider(provider Provider) {
+	providers.Lock()
+	defer providers.Unlock()
+	providers.byname[provider.Name()] = provider
+}
+
+// Get a Provider by its name. An empty name returns the DefaultProvider.
+func GetProvider(name string) (Provider, error) {
+	if name == "" {
+		return DefaultProvider, nil
+	}
+	providers.RLock()
+	defer providers.RUnlock()
+	provider, found := providers.byname[name]
+	if !found {
+		msg := fmt.Sprintf("unknown dictionary %q", name)
+		return nil, errors.New(msg)
+	}
+	return provider, nil
+}
+
+// Names of all selectable providers, sorted.
+func ProviderNames() []string {
+	providers.RLock()
+	defer providers.RUnlock()
+	names := make([]string, 0, len(providers.byname))
+	for name := range providers.byname {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (provider *NaverProvider) Name() string {
+	return provider.ProviderName
+}
+
+// Copy the provider, sending requests with another Client, e.g.
+// KoreanEnglish.WithClient(DefaultClient.WithLane(Batch)) for batch jobs.
+func (provider *NaverProvider) WithClient(client *Client) *NaverProvider {
+	copied := *provider
+	copied.Client = client
+	return &copied
+}
+
+// Copy the provider, sending its Naver requests in another lane of the same
+// RateLimiter, e.g. InLane(provider, Batch) for exports. Providers that send
+// no requests are returned as they are.
+func InLane(provider Provider, lane Lane) Provider {
+	switch provider := provider.(type) {
+	case *NaverProvider:
+		return provider.WithClient(provider.client().WithLane(lane))
+	case *FallbackProvider:
+		return &FallbackProvider{InLane(provider.Primary, lane), InLane(provider.Fallback, lane)}
+	}
+	return provider
+}
+
+// Get the Client requests are sent with.
+func (provider *NaverProvider) client() *Client {
+	if provider.Client != nil {
+		return provider.Client
+	}
+	return DefaultClient
+}
+
+// Format Search Term into Naver Dictionary Entry Url.
+func (provider *NaverProvider) GetEntryUrl(searchterm string) (string, error) {
+	// Note: Korean Search Term MUST be utf-8 encoded!
+	Url, err := url.Parse(provider.Hostname)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse URL: %v", err)
+		return "", errors.New(msg)
+	}
+
+	Url.Path += "/api3/" + provider.Code + "/search"
+	parameters := url.Values{}
+	parameters.Add("query", searchterm)
+	parameters.Add("m", "mobile")
+	parameters.Add("range", "entrySearch")
+	Url.RawQuery = parameters.Encode()
+
+	entryurl := Url.String()
+	return entryurl, nil
+}
+
+// Format Entry Id into Naver Dictionary Search Url.
+func (provider *NaverProvider) GetSearchUrl(entryid string) (string, error) {
+	Url, err := url.Parse(provider.Hostname)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse URL: %v", err)
+		return "", errors.New(msg)
+	}
+
+	Url.Path += "/api/platform/" + provider.Code + "/entry"
+	parameters := url.Values{}
+	parameters.Add("entryId", entryid)
+	Url.RawQuery = parameters.Encode()
+
+	searchurl := Url.String()
+	return searchurl, nil
+}
+
+// Get Entry Information from Naver Dictionary
+func (provider *NaverProvider) GetEntryInfo(searchterm string) (map[string]interface{}, error) {
+	entryurl, errentryurl := provider.GetEntryUrl(searchterm)
+	if errentryurl != nil {
+		return nil, errentryurl
+	}
+	entryinfo, errentryinfo := provider.client().Fetch(entryurl, provider.Referer)
+	if errentryinfo != nil {
+		return nil, errentryinfo
+	}
+	if schema, found := UpstreamSchemas[provider.Code]; found {
+		DefaultSchemaMonitor.Check(provider.Code+"/entryinfo", entryinfo, schema.EntryInfo)
+	}
+	return entryinfo, nil
+}
+
+// Get Search Information from Naver Dictionary
+func (provider *NaverProvider) GetSearchInfo(entryid string) (map[string]interface{}, error) {
+	searchurl, errsearchurl := provider.GetSearchUrl(entryid)
+	if errsearchurl != nil {
+		return nil, errsearchurl
+	}
+	searchinfo, errsearchinfo := provider.client().Fetch(searchurl, provider.Referer)
+	if errsearchinfo != nil {
+		return nil, errsearchinfo
+	}
+	if schema, found := UpstreamSchemas[provider.Code]; found {
+		DefaultSchemaMonitor.Check(provider.Code+"/searchinfo", searchinfo, schema.SearchInfo)
+	}
+	return searchinfo, nil
+}
@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetProviderDefault(t *testing.T) {
+	got, error := GetProvider("")
+	if error != nil {
+		t.Errorf("GetProvider(%q) = %q; want no error", "", error)
+	}
+	if got != DefaultProvider {
+		t.Errorf("GetProvider(%q) = %v; want %v", "", got, DefaultProvider)
+	}
+}
+
+func TestGetProviderUnknown(t *testing.T) {
+	_, error := GetProvider("INVALID")
+	if error == nil {
+		t.Errorf("GetProvider(%q) = nil; want error", "INVALID")
+	}
+}
+
+func TestProviderNames(t *testing.T) {
+	names := ProviderNames()
+	for _, want := range []string{"kodict", "koen", "koja", "kozh"} {
+		provider, error := GetProvider(want)
+		if error != nil || provider.Name() != want {
+			t.Errorf("GetProvider(%q) = %v, %v; want provider named %q (names: %q)", want, provider, error, want, names)
+		}
+	}
+}
+
+func TestNaverProviderEntryUrl(t *testing.T) {
+	searchterm := "안녕"
+	want := "https://ja.dict.naver.com/api3/jako/search?m=mobile&query=%EC%95%88%EB%85%95&range=entrySearch"
+	got, error := KoreanJapanese.GetEntryUrl(searchterm)
+	if error != nil {
+		t.Errorf("KoreanJapanese.GetEntryUrl(%q) = %q; want no error", searchterm, error)
+	}
+	if got != want {
+		t.Errorf("KoreanJapanese.GetEntryUrl(%q) = %q; want %q", searchterm, got, want)
+	}
+}
+
+func TestNaverProviderSearchUrl(t *testing.T) {
+	entryid := "ac75d1845900457bbda2fdbc4fbaac05"
+	want := "https://ko.dict.naver.com/api/platform/koko/entry?entryId=ac75d1845900457bbda2fdbc4fbaac05"
+	got, error := KoreanKorean.GetSearchUrl(entryid)
+	if error != nil {
+		t.Errorf("KoreanKorean.GetSearchUrl(%q) = %q; want no error", entryid, error)
+	}
+	if got != want {
+		t.Errorf("KoreanKorean.GetSearchUrl(%q) = %q; want %q", entryid, got, want)
+	}
+}
+
+func TestInLane(t *testing.T) {
+	offline := &OfflineProvider{NewOfflineIndex()}
+	fallback := InLane(&FallbackProvider{KoreanEnglish, offline}, Batch).(*FallbackProvider)
+	primary := fallback.Primary.(*NaverProvider)
+	if primary.client().Lane != Batch || primary.client().Limiter != DefaultRateLimiter {
+		t.Errorf("InLane(fallback, Batch) client = %+v; want the DefaultRateLimiter in the Batch lane", primary.client())
+	}
+	if fallback.Fallback != offline {
+		t.Errorf("InLane(fallback, Batch) fallback = %v; want %v", fallback.Fallback, offline)
+	}
+	if KoreanEnglish.client().Lane != Interactive {
+		t.Errorf("InLane() changed the lane of KoreanEnglish")
+	}
+}
+
+func TestInLaneQueuesBehindInteractive(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.URL.Query().Get("query"))
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(100, 1)
+	limiter.BatchEvery = 100
+	client := &Client{HTTPClient: server.Client(), Limiter: limiter}
+	interactive := &NaverProvider{ProviderName: "test", Code: "enko", Hostname: server.URL, Client: client}
+	batch := InLane(interactive, Batch).(*NaverProvider)
+
+	// Queue an export lookup, then 2 interactive ones, while no tokens are left.
+	host := server.Listener.Addr().String()
+	limiter.Wait(context.Background(), host, Interactive) // Use up the burst.
+	limiter.mu.Lock()                                     // Hold back grants until everyone is queued.
+	limiter.hosts[host].tokens = -1
+	limiter.mu.Unlock()
+	var wg sync.WaitGroup
+	lookup := func(provider *NaverProvider, word string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entryurl, _ := provider.GetEntryUrl(word)
+			provider.client().Fetch(entryurl, provider.Referer)
+		}()
+		time.Sleep(time.Millisecond) // Keep arrival order.
+	}
+	lookup(batch, "export")
+	lookup(interactive, "one")
+	lookup(interactive, "two")
+	wg.Wait()
+
+	want := []string{"one", "two", "export"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("request order = %q; want %q", order, want)
+	}
+}
+
+func TestNaverProviderSchemaChecks(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer upstream.Close()
+	saved := DefaultSchemaMonitor
+	defer func() { DefaultSchemaMonitor = saved }()
+	DefaultSchemaMonitor = NewSchemaMonitor()
+
+	for _, provider := range []*NaverProvider{KoreanEnglish, KoreanKorean, KoreanJapanese, KoreanChinese} {
+		client, _ := NewClient(WithBaseUrl(upstream.URL))
+		provider = provider.WithClient(client)
+		provider.GetEntryInfo("사랑")
+		provider.GetSearchInfo("1")
+	}
+	// Only koen has a known schema, and each dictionary would be checked apart.
+	want := map[string]int{"koen/entryinfo": 1, "koen/searchinfo": 1}
+	if got := DefaultSchemaMonitor.Report().Drifted; !reflect.DeepEqual(got, want) {
+		t.Errorf("Report().Drifted = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Start a fake HTTP proxy answering every request with status.
+func startproxy(status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"host": %q}`, r.URL.Host)
+	}))
+}
+
+func TestNewProxyPoolInvalid(t *testing.T) {
+	for _, proxyurl := range []string{"ftp://10.0.0.1:21", "INVALID"} {
+		_, error := NewProxyPool([]string{proxyurl})
+		if error == nil {
+			t.Errorf("NewProxyPool(%q) = nil; want error", proxyurl)
+		}
+	}
+}
+
+func TestProxyPoolRotation(t *testing.T) {
+	blockedproxy := startproxy(http.StatusForbidden)
+	defer blockedproxy.Close()
+	workingproxy := startproxy(http.StatusOK)
+	defer workingproxy.Close()
+
+	pool, errpool := NewProxyPool([]string{blockedproxy.URL, "http://user:secret@" + workingproxy.Listener.Addr().String()})
+	if errpool != nil {
+		t.Fatalf("NewProxyPool() = %q; want no error", errpool)
+	}
+	client, _ := NewClient(WithProxyPool(pool))
+	for i := 0; i < 2; i++ {
+		got, error := client.Fetch("http://korean.dict.naver.com/api", "")
+		if error != nil || got["host"] != "korean.dict.naver.com" {
+			t.Errorf("Fetch() through pool = %v, %v; want proxied response", got, error)
+		}
+	}
+
+	stats := pool.Stats()
+	if stats[0].Healthy || stats[0].Requests != 1 || stats[0].Failures != 1 {
+		t.Errorf("Stats()[0] = %+v; want 1 failed request, unhealthy", stats[0])
+	}
+	if !stats[1].Healthy || stats[1].Requests != 2 || stats[1].Failures != 0 {
+		t.Errorf("Stats()[1] = %+v; want 2 requests, healthy", stats[1])
+	}
+	if stats[1].Url != "http://user:xxxxx@"+workingproxy.Listener.Addr().String() {
+		t.Errorf("Stats()[1].Url = %q; want password redacted", stats[1].Url)
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"ua": %q, "lang": %q, "referer": %q}`, r.UserAgent(), r.Header.Get("Accept-Language"), r.Referer())
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(WithUserAgent("Mozilla/5.0"), WithAcceptLanguage("ko-KR"))
+	got, error := client.Fetch(server.URL, "https://korean.dict.naver.com/koendict/")
+	if error != nil {
+		t.Fatalf("Fetch() = %q; want no error", error)
+	}
+	if got["ua"] != "Mozilla/5.0" || got["lang"] != "ko-KR" || got["referer"] != "https://korean.dict.naver.com/koendict/" {
+		t.Errorf("Fetch() sent %v; want configured headers and Referer", got)
+	}
+}
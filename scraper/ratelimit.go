@@ -0,0 +1,170 @@
+package scraper
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Lane is the priority of a caller of a RateLimiter.
+type Lane int
+
+const (
+	Interactive Lane = iota // People waiting on a reply, e.g. REST and bot lookups.
+	Batch                   // Jobs that can wait, e.g. exports of word lists.
+)
+
+func (lane Lane) String() string {
+	if lane == Batch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// Wait time metrics of every RateLimiter, by lane.
+var ratelimitstats = expvar.NewMap("naverdict_ratelimit")
+
+// RateLimiter is a token bucket per host. When both lanes are waiting,
+// Interactive requests go first, but every BatchEvery-th token goes to
+// Batch so that batch jobs are never starved.
+type RateLimiter struct {
+	Rate       float64 // Requests per second per host.
+	Burst      int     // Requests that can be sent at once after a quiet period.
+	BatchEvery int     // Under contention, every BatchEvery-th token goes to Batch. Zero means 4.
+
+	mu    sync.Mutex
+	hosts map[string]*bucket
+}
+
+// bucket holds the tokens and waiting requests of one host.
+type bucket struct {
+	tokens  float64
+	last    time.Time
+	waiting [2][]chan struct{} // By lane, in arrival order.
+	streak  int                // Interactive grants since the last Batch grant.
+	timer   *time.Timer
+}
+
+// Create a RateLimiter allowing rate requests per second per host, in bursts of up to burst.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{Rate: rate, Burst: burst, hosts: map[string]*bucket{}}
+}
+
+// Add the tokens earned since the last refill.
+func (limiter *RateLimiter) refill(host *bucket, now time.Time) {
+	host.tokens += now.Sub(host.last).Seconds() * limiter.Rate
+	if host.tokens > float64(limiter.Burst) {
+		host.tokens = float64(limiter.Burst)
+	}
+	host.last = now
+}
+
+// Wait until a request to host may be sent, or ctx is done.
+// A Rate of zero or less does not limit requests.
+func (limiter *RateLimiter) Wait(ctx context.Context, host string, lane Lane) error {
+	if limiter.Rate <= 0 {
+		return nil
+	}
+	start := time.Now()
+	limiter.mu.Lock()
+	if limiter.hosts == nil {
+		limiter.hosts = map[string]*bucket{}
+	}
+	hostbucket, found := limiter.hosts[host]
+	if !found {
+		hostbucket = &bucket{tokens: float64(limiter.Burst), last: start}
+		limiter.hosts[host] = hostbucket
+	}
+	limiter.refill(hostbucket, start)
+	if len(hostbucket.waiting[Interactive]) == 0 && len(hostbucket.waiting[Batch]) == 0 && hostbucket.tokens >= 1 {
+		hostbucket.tokens--
+		limiter.mu.Unlock()
+		recordwait(lane, 0)
+		return nil
+	}
+	granted := make(chan struct{})
+	hostbucket.waiting[lane] = append(hostbucket.waiting[lane], granted)
+	limiter.schedule(hostbucket)
+	limiter.mu.Unlock()
+
+	select {
+	case <-granted:
+		recordwait(lane, time.Since(start))
+		return nil
+	case <-ctx.Done():
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		select {
+		case <-granted:
+			hostbucket.tokens++ // Granted while giving up, so hand the token back.
+		default:
+			hostbucket.waiting[lane] = removewaiter(hostbucket.waiting[lane], granted)
+		}
+		return ctx.Err()
+	}
+}
+
+// Remove a waiter from a lane.
+func removewaiter(waiting []chan struct{}, waiter chan struct{}) []chan struct{} {
+	for i, other := range waiting {
+		if other == waiter {
+			return append(waiting[:i], waiting[i+1:]...)
+		}
+	}
+	return waiting
+}
+
+// Pick the lane to grant the next token to.
+func (limiter *RateLimiter) nextlane(hostbucket *bucket) Lane {
+	batchevery := limiter.BatchEvery
+	if batchevery <= 0 {
+		batchevery = 4
+	}
+	if len(hostbucket.waiting[Batch]) == 0 {
+		return Interactive
+	}
+	if len(hostbucket.waiting[Interactive]) == 0 || hostbucket.streak >= batchevery-1 {
+		return Batch
+	}
+	return Interactive
+}
+
+// Grant available tokens to waiting requests, and set a timer for the rest.
+// Must be called with limiter.mu held.
+func (limiter *RateLimiter) schedule(hostbucket *bucket) {
+	limiter.refill(hostbucket, time.Now())
+	for hostbucket.tokens >= 1 && len(hostbucket.waiting[Interactive])+len(hostbucket.waiting[Batch]) > 0 {
+		lane := limiter.nextlane(hostbucket)
+		if lane == Batch {
+			hostbucket.streak = 0
+		} else {
+			hostbucket.streak++
+		}
+		close(hostbucket.waiting[lane][0])
+		hostbucket.waiting[lane] = hostbucket.waiting[lane][1:]
+		hostbucket.tokens--
+	}
+	if len(hostbucket.waiting[Interactive])+len(hostbucket.waiting[Batch]) == 0 || hostbucket.timer != nil {
+		return
+	}
+	delay := time.Duration((1 - hostbucket.tokens) / limiter.Rate * float64(time.Second))
+	hostbucket.timer = time.AfterFunc(delay, func() {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		hostbucket.timer = nil
+		limiter.schedule(hostbucket)
+	})
+}
+
+// Record how long a request waited for a token.
+func recordwait(lane Lane, wait time.Duration) {
+	ratelimitstats.Add(lane.String()+"_requests", 1)
+	ratelimitstats.AddFloat(lane.String()+"_wait_seconds", wait.Seconds())
+	if wait > 0 {
+		ratelimitstats.Add(lane.String()+"_delayed", 1)
+	}
+}
@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	limiter := NewRateLimiter(10, 3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Wait(context.Background(), "a", Interactive)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait() for a burst of 3 took %v; want no wait", elapsed)
+	}
+	limiter.Wait(context.Background(), "a", Interactive)
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Wait() beyond the burst took %v; want about 100ms", elapsed)
+	}
+}
+
+func TestRateLimiterPerHost(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+	start := time.Now()
+	limiter.Wait(context.Background(), "a", Interactive)
+	limiter.Wait(context.Background(), "b", Interactive)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait() for two hosts took %v; want no wait", elapsed)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+	limiter.Wait(context.Background(), "a", Interactive)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	error := limiter.Wait(ctx, "a", Interactive)
+	if error == nil {
+		t.Errorf("Wait() with an expired context = nil; want error")
+	}
+}
+
+func TestRateLimiterLanes(t *testing.T) {
+	limiter := NewRateLimiter(100, 1)
+	limiter.BatchEvery = 3
+	limiter.Wait(context.Background(), "a", Interactive) // Use up the burst.
+
+	// Queue 3 batch requests, then 4 interactive ones, while no tokens are left.
+	var mu sync.Mutex
+	var order []Lane
+	var wg sync.WaitGroup
+	queue := func(lane Lane) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.Wait(context.Background(), "a", lane)
+			mu.Lock()
+			order = append(order, lane)
+			mu.Unlock()
+		}()
+		time.Sleep(time.Millisecond) // Keep arrival order.
+	}
+	limiter.mu.Lock() // Hold back grants until everyone is queued.
+	limiter.hosts["a"].tokens = -1
+	limiter.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		queue(Batch)
+	}
+	for i := 0; i < 4; i++ {
+		queue(Interactive)
+	}
+	wg.Wait()
+
+	want := []Lane{Interactive, Interactive, Batch, Interactive, Interactive, Batch, Batch}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("grant order = %v; want %v", order, want)
+	}
+}
@@ -0,0 +1,284 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Renderer formats an Entry as a message.
+type Renderer interface {
+	Name() string // Identifies the renderer, e.g. in the `format=` REST parameter.
+	Render(entry Entry) string
+}
+
+// CheckedRenderer is a Renderer that can fail, e.g. a template on an entry
+// unlike the sample it was checked with. Its Render falls back to plain text.
+type CheckedRenderer interface {
+	Renderer
+	Execute(entry Entry) (string, error)
+}
+
+// Render an Entry, reporting the errors of CheckedRenderers instead of
+// falling back to plain text.
+func RenderEntry(renderer Renderer, entry Entry) (string, error) {
+	if checked, found := renderer.(CheckedRenderer); found {
+		return checked.Execute(entry)
+	}
+	return renderer.Render(entry), nil
+}
+
+// plainrenderer keeps the layout of Buildmessage.
+type plainrenderer struct{}
+
+func (plainrenderer) Name() string { return "plain" }
+
+func (renderer plainrenderer) Render(entry Entry) string {
+	return renderer.RenderDetail(DefaultLocale, DefaultDetail, entry)
+}
+
+func (renderer plainrenderer) RenderIn(locale string, entry Entry) string {
+	return renderer.RenderDetail(locale, DefaultDetail, entry)
+}
+
+func (plainrenderer) RenderDetail(locale string, detail Detail, entry Entry) string {
+	entry = detail.Apply(entry)
+	message := BuildmessageIn(locale, entry.dictinfo(locale, true))
+	if message != "" && len(entry.Related) > 0 {
+		message += "\n----------\n" + Translate(locale, LabelRelated) + "\n" + strings.Join(entry.Related, ", ")
+	}
+	return message
+}
+
+// Format renders an Entry in a markup language, given how to escape text
+// and how to wrap emphasised text in each style.
+type Format struct {
+	FormatName string
+	Escape     func(text string) string
+	Wrap       func(style string) (string, string) // Opening and closing syntax of a style.
+}
+
+// Plain text, as built by Buildmessage.
+var PlainText Renderer = plainrenderer{}
+
+// Telegram MarkdownV2, see https://core.telegram.org/bots/api#markdownv2-style
+var MarkdownV2 = &Format{
+	FormatName: "markdownv2",
+	Escape:     EscapeMarkdownV2,
+	Wrap: func(style string) (string, string) {
+		switch style {
+		case StyleBold:
+			return "*", "*"
+		case StyleItalic:
+			return "_", "_"
+		}
+		return "__", "__" // Underline, also used for highlights.
+	},
+}
+
+// Telegram HTML, see https://core.telegram.org/bots/api#html-style
+var TelegramHTML = &Format{
+	FormatName: "html",
+	Escape:     EscapeHTML,
+	Wrap: func(style string) (string, string) {
+		if style == StyleHighlight {
+			return "<u>", "</u>" // Telegram has no <mark>.
+		}
+		return htmlwrap(style)
+	},
+}
+
+// CommonMark, see https://spec.commonmark.org
+var CommonMark = &Format{
+	FormatName: "commonmark",
+	Escape:     EscapeCommonMark,
+	Wrap: func(style string) (string, string) {
+		switch style {
+		case StyleItalic, StyleUnderline:
+			return "*", "*" // CommonMark has no underline.
+		}
+		return "**", "**"
+	},
+}
+
+// Renderer used when none is requested.
+var DefaultRenderer Renderer = PlainText
+
+// renderers stores every renderer selectable by name.
+var renderers = struct {
+	sync.RWMutex
+	byname map[string]Renderer
+}{
+	byname: map[string]Renderer{},
+}
+
+func init() {
+	RegisterRenderer(PlainText)
+	RegisterRenderer(MarkdownV2)
+	RegisterRenderer(TelegramHTML)
+	RegisterRenderer(CommonMark)
+}
+
+// Make a Renderer selectable by its name, case-insensitively, replacing
+// any renderer of the same name.
+func RegisterRenderer(renderer Renderer) {
+	renderers.Lock()
+	defer renderers.Unlock()
+	renderers.byname[strings.ToLower(renderer.Name())] = renderer
+}
+
+// Get a Renderer by its name. An empty name returns the DefaultRenderer.
+func GetRenderer(name string) (Renderer, error) {
+	if name == "" {
+		return DefaultRenderer, nil
+	}
+	renderers.RLock()
+	defer renderers.RUnlock()
+	renderer, found := renderers.byname[strings.ToLower(name)]
+	if !found {
+		msg := fmt.Sprintf("unknown format %q", name)
+		return nil, errors.New(msg)
+	}
+	return renderer, nil
+}
+
+// Names of all selectable renderers, sorted.
+func RendererNames() []string {
+	renderers.RLock()
+	defer renderers.RUnlock()
+	names := make([]string, 0, len(renderers.byname))
+	for name := range renderers.byname {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Escape the characters reserved by Telegram MarkdownV2.
+func EscapeMarkdownV2(text string) string {
+	return escapeall(text, "\\_*[]()~`>#+-=|{}.!")
+}
+
+// Escape the characters reserved by Telegram HTML.
+func EscapeHTML(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(text)
+}
+
+// Get the HTML tags of a style, with <mark> for highlights.
+func htmlwrap(style string) (string, string) {
+	switch style {
+	case StyleBold:
+		return "<b>", "</b>"
+	case StyleItalic:
+		return "<i>", "</i>"
+	case StyleUnderline:
+		return "<u>", "</u>"
+	}
+	return "<mark>", "</mark>"
+}
+
+// Escape the characters that CommonMark could read as markup.
+func EscapeCommonMark(text string) string {
+	return escapeall(text, "\\_*[]()~`>#+-=|{}.!<&")
+}
+
+// Prefix every reserved character with a backslash.
+func escapeall(text string, reserved string) string {
+	var escaped strings.Builder
+	for _, char := range text {
+		if strings.ContainsRune(reserved, char) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
+
+func (format *Format) Name() string {
+	return format.FormatName
+}
+
+// Escape and wrap text in a style.
+func (format *Format) styled(style string, text string) string {
+	if text == "" {
+		return ""
+	}
+	opening, closing := format.Wrap(style)
+	return opening + format.Escape(text) + closing
+}
+
+// Render an Entry in the layout of Buildmessage, with the title and
+// labels emphasised and senses grouped by part of speech.
+func (format *Format) Render(entry Entry) string {
+	return format.RenderIn(DefaultLocale, entry)
+}
+
+// Render an Entry with labels in a locale.
+func (format *Format) RenderIn(locale string, entry Entry) string {
+	return format.RenderDetail(locale, DefaultDetail, entry)
+}
+
+// Render an Entry with labels in a locale, at a Detail.
+func (format *Format) RenderDetail(locale string, detail Detail, entry Entry) string {
+	entry = detail.Apply(entry)
+	separator := format.Escape("----------")
+	lines := []string{}
+	appendline := func(parts ...string) {
+		line := Buildsentence("", parts)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	stars := strings.Repeat("★", entry.Importance)
+	appendline(format.Escape(entry.TopikLevel.LabelIn(locale)), format.Escape(stars))
+	appendline(format.styled(StyleBold, entry.Title), format.Escape(entry.Hanja))
+	glosses := make([]string, len(entry.Glosses))
+	for i, gloss := range entry.Glosses {
+		glosses[i] = format.Escape(fmt.Sprintf("%d.%s", i+1, gloss))
+	}
+	appendline(glosses...)
+	if entry.Romanisation != "" || entry.Pronunciation != "" {
+		lines = append(lines, separator)
+		appendline(format.styled(StyleItalic, Translate(locale, LabelPronunciation)), format.Escape(entry.Romanisation), format.Escape(entry.Pronunciation))
+	}
+
+	senses := map[int]Sense{}
+	for _, sense := range entry.Senses {
+		senses[sense.Number] = sense
+	}
+	groups := entry.Groups
+	if groups == nil {
+		groups = GroupSenses(entry.Senses)
+	}
+	for _, group := range groups {
+		lines = append(lines, separator)
+		partofspeech := group.PartOfSpeech
+		if group.PartOfSpeechEnglish != "" {
+			partofspeech += " (" + group.PartOfSpeechEnglish + ")"
+		}
+		appendline(format.styled(StyleItalic, partofspeech))
+		for i, number := range group.Senses {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			sense := senses[number]
+			appendline(format.styled(StyleBold, fmt.Sprintf("%d.%s", sense.Number, sense.Gloss)))
+			appendline(format.Escape(sense.Definition))
+			appendline(format.Escape(sense.KoreanDefinition))
+			for _, example := range sense.Examples {
+				appendline(format.Escape("||"), example.Render(format.Escape, format.Wrap))
+			}
+		}
+	}
+	if len(entry.Related) > 0 {
+		lines = append(lines, separator)
+		appendline(format.styled(StyleItalic, Translate(locale, LabelRelated)), format.Escape(strings.Join(entry.Related, ", ")))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+var renderentry = Entry{
+	Title:         "안녕",
+	Hanja:         "安寧",
+	Romanisation:  "an-nyeong",
+	Pronunciation: "안녕",
+	TopikLevel:    TopikElementary,
+	Importance:    3,
+	Glosses:       []string{"hello", "peace"},
+	Senses: []Sense{
+		{Number: 1, PartOfSpeech: "명사", PartOfSpeechEnglish: "noun", Gloss: "peace", Definition: "The state of being at peace.",
+			Examples: []Markup{{Text: "가족의 안녕을 빌다.", Spans: []Span{{10, 16, StyleHighlight}}}}},
+		{Number: 2, PartOfSpeech: "감탄사", PartOfSpeechEnglish: "interjection", Gloss: "hi (informal)", KoreanDefinition: "인사말."},
+	},
+}
+
+func TestRenderPlainText(t *testing.T) {
+	got := PlainText.Render(Entry{})
+	if got != "" {
+		t.Errorf("PlainText.Render(Entry{}) = %q; want empty string", got)
+	}
+	entry, _ := ScrapeEntry(examplesearchinfo)
+	dictinfo, _ := Scrape(examplesearchinfo)
+	got = PlainText.Render(entry)
+	if got != Buildmessage(dictinfo) {
+		t.Errorf("PlainText.Render() = %q; want %q", got, Buildmessage(dictinfo))
+	}
+}
+
+func TestRenderMarkdownV2(t *testing.T) {
+	got := MarkdownV2.Render(renderentry)
+	want := "\\(TOPIK Elementary\\) ★★★\n" +
+		"*안녕* 安寧\n" +
+		"1\\.hello 2\\.peace\n" +
+		"\\-\\-\\-\\-\\-\\-\\-\\-\\-\\-\n" +
+		"_Pronunciation:_ an\\-nyeong 안녕\n" +
+		"\\-\\-\\-\\-\\-\\-\\-\\-\\-\\-\n" +
+		"_명사 \\(noun\\)_\n" +
+		"*1\\.peace*\n" +
+		"The state of being at peace\\.\n" +
+		"\\|\\| 가족의 __안녕__을 빌다\\.\n" +
+		"\\-\\-\\-\\-\\-\\-\\-\\-\\-\\-\n" +
+		"_감탄사 \\(interjection\\)_\n" +
+		"*2\\.hi \\(informal\\)*\n" +
+		"인사말\\."
+	if got != want {
+		t.Errorf("MarkdownV2.Render() = %q; want %q", got, want)
+	}
+}
+
+func TestRenderTelegramHTML(t *testing.T) {
+	entry := Entry{Title: "<script>", Senses: []Sense{{Number: 1, Gloss: "a & b",
+		Examples: []Markup{{Text: "x < y", Spans: []Span{{0, 1, StyleBold}}}}}}}
+	got := TelegramHTML.Render(entry)
+	want := "<b>&lt;script&gt;</b>\n----------\n<b>1.a &amp; b</b>\n|| <b>x</b> &lt; y"
+	if got != want {
+		t.Errorf("TelegramHTML.Render() = %q; want %q", got, want)
+	}
+}
+
+func TestEscapeMarkdownV2(t *testing.T) {
+	reserved := "_*[]()~`>#+-=|{}.!\\"
+	got := EscapeMarkdownV2(reserved)
+	want := "\\_\\*\\[\\]\\(\\)\\~\\`\\>\\#\\+\\-\\=\\|\\{\\}\\.\\!\\\\"
+	if got != want {
+		t.Errorf("EscapeMarkdownV2(%q) = %q; want %q", reserved, got, want)
+	}
+}
+
+func TestEscapeCommonMark(t *testing.T) {
+	got := EscapeCommonMark("**not bold** <b> &amp;")
+	want := "\\*\\*not bold\\*\\* \\<b\\> \\&amp;"
+	if got != want {
+		t.Errorf("EscapeCommonMark() = %q; want %q", got, want)
+	}
+}
+
+func TestGetRenderer(t *testing.T) {
+	for _, name := range []string{"plain", "markdownv2", "html", "commonmark", "MarkdownV2"} {
+		renderer, error := GetRenderer(name)
+		if error != nil {
+			t.Errorf("GetRenderer(%q) = %q; want no error", name, error)
+			continue
+		}
+		if renderer.Name() != strings.ToLower(name) {
+			t.Errorf("GetRenderer(%q).Name() = %q; want %q", name, renderer.Name(), strings.ToLower(name))
+		}
+	}
+	_, error := GetRenderer("INVALID")
+	if error == nil {
+		t.Errorf("GetRenderer(%q) = nil; want error", "INVALID")
+	}
+}
@@ -0,0 +1,176 @@
+package scraper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Modes of a RecordReplayTransport.
+const (
+	ModeRecord = "record" // Send requests upstream and store the responses as fixtures.
+	ModeReplay = "replay" // Serve responses from fixtures only.
+)
+
+// RecordReplayTransport records request/response pairs as fixture files, or
+// serves them back, so that the scraper can run without network access.
+type RecordReplayTransport struct {
+	Mode      string            // ModeRecord or ModeReplay.
+	Dir       string            // Directory of the fixture files.
+	Transport http.RoundTripper // Sends requests in ModeRecord. Nil uses http.DefaultTransport.
+}
+
+// Fixture is a recorded response, stored as JSON.
+type Fixture struct {
+	Method string      `json:"method"`
+	Url    string      `json:"url"` // Normalized with NormaliseUrl.
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Normalise a URL for matching fixtures: the scheme and default port are
+// dropped, the host is lowercased and query parameters are sorted.
+func NormaliseUrl(rawurl *url.URL) string {
+	host := strings.ToLower(rawurl.Hostname())
+	port := rawurl.Port()
+	if port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	path := rawurl.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	normalised := host + path
+	if query := rawurl.Query(); len(query) > 0 {
+		normalised += "?" + query.Encode() // Encode sorts by key.
+	}
+	return normalised
+}
+
+var fixturenamepattern = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Get the fixture file name of a request, e.g.
+// GET_korean_dict_naver_com_api3_koen_search_1a2b3c4d.json
+func FixtureName(method string, normalised string) string {
+	hash := sha256.Sum256([]byte(method + " " + normalised))
+	path, _, _ := strings.Cut(normalised, "?")
+	readable := strings.Trim(fixturenamepattern.ReplaceAllString(path, "_"), "_")
+	return fmt.Sprintf("%s_%s_%s.json", method, readable, hex.EncodeToString(hash[:4]))
+}
+
+func (transport *RecordReplayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	normalised := NormaliseUrl(request.URL)
+	path := filepath.Join(transport.Dir, FixtureName(request.Method, normalised))
+	switch transport.Mode {
+	case ModeReplay:
+		return transport.replay(request, path, normalised)
+	case ModeRecord:
+		return transport.record(request, path, normalised)
+	}
+	msg := fmt.Sprintf("unknown record/replay mode %q", transport.Mode)
+	return nil, errors.New(msg)
+}
+
+// Serve a response from its fixture file.
+func (transport *RecordReplayTransport) replay(request *http.Request, path string, normalised string) (*http.Response, error) {
+	data, errread := os.ReadFile(path)
+	if errors.Is(errread, os.ErrNotExist) {
+		msg := fmt.Sprintf("no recorded response for %s %s", request.Method, normalised)
+		return nil, errors.New(msg)
+	}
+	if errread != nil {
+		msg := fmt.Sprintf("cannot read fixture: %v", errread)
+		return nil, errors.New(msg)
+	}
+	var fixture Fixture
+	errdecode := json.Unmarshal(data, &fixture)
+	if errdecode != nil {
+		msg := fmt.Sprintf("cannot decode fixture %s: %v", path, errdecode)
+		return nil, errors.New(msg)
+	}
+	header := fixture.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	response := &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       request,
+	}
+	return response, nil
+}
+
+// Send a request upstream and store its response as a fixture file.
+func (transport *RecordReplayTransport) record(request *http.Request, path string, normalised string) (*http.Response, error) {
+	upstream := transport.Transport
+	if upstream == nil {
+		upstream = http.DefaultTransport
+	}
+	response, errupstream := upstream.RoundTrip(request)
+	if errupstream != nil {
+		return nil, errupstream
+	}
+	body, errbody := io.ReadAll(response.Body)
+	response.Body.Close()
+	if errbody != nil {
+		msg := fmt.Sprintf("cannot read response: %v", errbody)
+		return nil, errors.New(msg)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := Fixture{request.Method, normalised, response.StatusCode, response.Header.Clone(), string(body)}
+	fixture.Header.Del("Set-Cookie") // Never store session cookies in fixtures.
+	fixture.Header.Del("Date")
+	data, errencode := json.MarshalIndent(fixture, "", "  ")
+	if errencode != nil {
+		msg := fmt.Sprintf("cannot encode fixture: %v", errencode)
+		return nil, errors.New(msg)
+	}
+	errmkdir := os.MkdirAll(transport.Dir, 0o755)
+	if errmkdir != nil {
+		msg := fmt.Sprintf("cannot create fixture directory: %v", errmkdir)
+		return nil, errors.New(msg)
+	}
+	errwrite := os.WriteFile(path, append(data, '\n'), 0o644)
+	if errwrite != nil {
+		msg := fmt.Sprintf("cannot write fixture: %v", errwrite)
+		return nil, errors.New(msg)
+	}
+	return response, nil
+}
+
+// Record or replay every request of the DefaultClient, using fixtures in dir.
+// An empty mode leaves the DefaultClient unchanged.
+func UseRecordReplay(mode string, dir string) error {
+	switch mode {
+	case "":
+		return nil
+	case ModeRecord, ModeReplay:
+	default:
+		msg := fmt.Sprintf("unknown record/replay mode %q, want %s or %s", mode, ModeRecord, ModeReplay)
+		return errors.New(msg)
+	}
+	if dir == "" {
+		return errors.New("empty fixture directory")
+	}
+	upstream := DefaultClient.HTTPClient.Transport
+	DefaultClient.HTTPClient.Transport = &RecordReplayTransport{mode, dir, upstream}
+	return nil
+}
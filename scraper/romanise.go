@@ -0,0 +1,41 @@
+package scraper
+
+import (
+	"strings"
+)
+
+// Revised Romanization of Korean, indexed like initialjamo, medialjamo and finaljamo.
+var initialroman = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+var medialroman = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+var finalroman = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+
+// Romanise Hangul using the Revised Romanization of Korean.
+// Sound changes between syllables are not applied, so pass a pronunciation
+// (e.g. 궁물 rather than 국물) for the best result.
+func Romanise(hangul string) string {
+	var builder strings.Builder
+	previousfinal := 0
+	for _, r := range hangul {
+		if r < '가' || r > '힣' {
+			builder.WriteRune(r)
+			previousfinal = 0
+			continue
+		}
+		index := int(r - '가')
+		initial := index / (21 * 28)
+		medial := (index % (21 * 28)) / 28
+		final := index % 28
+
+		// ㄹ starting a syllable is r, except after ㄹ where it is l (ㄹㄹ is ll).
+		if initial == 5 && previousfinal == 8 {
+			builder.WriteString("l")
+		} else {
+			builder.WriteString(initialroman[initial])
+		}
+		builder.WriteString(medialroman[medial])
+		builder.WriteString(finalroman[final])
+		previousfinal = final
+	}
+	romanised := builder.String()
+	return romanised
+}
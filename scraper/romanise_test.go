@@ -0,0 +1,21 @@
+package scraper
+
+import (
+	"testing"
+)
+
+func TestRomanise(t *testing.T) {
+	tests := map[string]string{
+		"사랑":   "sarang",
+		"강아지":  "gangaji",
+		"설날":   "seolnal",
+		"빨래":   "ppallae",
+		"한구거!": "hangugeo!", // Pronunciation of 한국어.
+	}
+	for hangul, want := range tests {
+		got := Romanise(hangul)
+		if got != want {
+			t.Errorf("Romanise(%q) = %q; want %q", hangul, got, want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Annotations of ruby renderers.
+const (
+	AnnotateRomanisation = "romanisation" // Romanisation over Hangul words.
+	AnnotateHanja        = "hanja"        // Hanja over the headword, a Sino-Korean word.
+)
+
+// RubyRenderer renders an Entry as an HTML fragment for web pages, with
+// <ruby> annotations over the headword and the Hangul of examples. Elements
+// have naverdict- classes to be styled by the page.
+type RubyRenderer struct {
+	RendererName string
+	Annotation   string // AnnotateRomanisation or AnnotateHanja.
+}
+
+// HTML with romanisation over Hangul, e.g. for beginners.
+var RubyRomanised = &RubyRenderer{RendererName: "ruby", Annotation: AnnotateRomanisation}
+
+// HTML with hanja over Sino-Korean headwords.
+var RubyHanja = &RubyRenderer{RendererName: "ruby_hanja", Annotation: AnnotateHanja}
+
+func init() {
+	RegisterRenderer(RubyRomanised)
+	RegisterRenderer(RubyHanja)
+}
+
+// Get the RubyRenderer of an annotation. An empty annotation returns
+// RubyRomanised.
+func GetRubyRenderer(annotation string) (*RubyRenderer, error) {
+	switch strings.ToLower(annotation) {
+	case "", AnnotateRomanisation, "romanization":
+		return RubyRomanised, nil
+	case AnnotateHanja:
+		return RubyHanja, nil
+	}
+	msg := fmt.Sprintf("unknown annotation %q, want romanisation or hanja", annotation)
+	return nil, errors.New(msg)
+}
+
+func (renderer *RubyRenderer) Name() string {
+	return renderer.RendererName
+}
+
+// Render an Entry as an HTML fragment.
+func (renderer *RubyRenderer) Render(entry Entry) string {
+	return renderer.RenderIn(DefaultLocale, entry)
+}
+
+// Render an Entry with labels in a locale.
+func (renderer *RubyRenderer) RenderIn(locale string, entry Entry) string {
+	return renderer.RenderDetail(locale, DefaultDetail, entry)
+}
+
+// Render an Entry with labels in a locale, at a Detail.
+func (renderer *RubyRenderer) RenderDetail(locale string, detail Detail, entry Entry) string {
+	entry = detail.Apply(entry)
+	if entry.Title == "" {
+		return ""
+	}
+	lines := []string{`<article class="naverdict" lang="ko">`}
+
+	headword := `<h1 class="naverdict-headword">` + renderer.headword(entry) + `</h1>`
+	if entry.Hanja != "" && renderer.Annotation != AnnotateHanja {
+		headword += ` <span class="naverdict-hanja">` + EscapeHTML(entry.Hanja) + `</span>`
+	}
+	if label := entry.TopikLevel.LabelIn(locale); label != "" {
+		headword += ` <span class="naverdict-topik" lang="` + locale + `">` + EscapeHTML(strings.Trim(label, "()")) + `</span>`
+	}
+	if entry.Importance > 0 {
+		headword += ` <span class="naverdict-importance">` + strings.Repeat("★", entry.Importance) + `</span>`
+	}
+	lines = append(lines, headword)
+	if entry.Pronunciation != "" {
+		lines = append(lines, `<p class="naverdict-pronunciation">[`+EscapeHTML(entry.Pronunciation)+`]</p>`)
+	}
+	if len(entry.Glosses) > 0 {
+		lines = append(lines, `<ol class="naverdict-glosses" lang="en">`)
+		for _, gloss := range entry.Glosses {
+			lines = append(lines, `<li>`+EscapeHTML(gloss)+`</li>`)
+		}
+		lines = append(lines, `</ol>`)
+	}
+
+	senses := map[int]Sense{}
+	for _, sense := range entry.Senses {
+		senses[sense.Number] = sense
+	}
+	groups := entry.Groups
+	if groups == nil {
+		groups = GroupSenses(entry.Senses)
+	}
+	for _, group := range groups {
+		lines = append(lines, `<section class="naverdict-group">`)
+		partofspeech := EscapeHTML(group.PartOfSpeech)
+		if group.PartOfSpeechEnglish != "" {
+			partofspeech += ` <span lang="en">(` + EscapeHTML(group.PartOfSpeechEnglish) + `)</span>`
+		}
+		lines = append(lines, `<h2 class="naverdict-part-of-speech">`+partofspeech+`</h2>`, `<ol class="naverdict-senses">`)
+		for _, number := range group.Senses {
+			sense := senses[number]
+			lines = append(lines, fmt.Sprintf(`<li value="%d">`, sense.Number))
+			if sense.Gloss != "" {
+				lines = append(lines, `<p class="naverdict-gloss" lang="en">`+EscapeHTML(sense.Gloss)+`</p>`)
+			}
+			if sense.Definition != "" {
+				lines = append(lines, `<p class="naverdict-definition" lang="en">`+EscapeHTML(sense.Definition)+`</p>`)
+			}
+			if sense.KoreanDefinition != "" {
+				lines = append(lines, `<p class="naverdict-definition">`+EscapeHTML(sense.KoreanDefinition)+`</p>`)
+			}
+			if len(sense.Examples) > 0 {
+				lines = append(lines, `<ul class="naverdict-examples">`)
+				for _, example := range sense.Examples {
+					annotated := example.Render(func(text string) string { return renderer.annotate(entry, text) }, htmlwrap)
+					lines = append(lines, `<li>`+annotated+`</li>`)
+				}
+				lines = append(lines, `</ul>`)
+			}
+			lines = append(lines, `</li>`)
+		}
+		lines = append(lines, `</ol>`, `</section>`)
+	}
+	if len(entry.Related) > 0 {
+		label := `<span lang="` + locale + `">` + EscapeHTML(Translate(locale, LabelRelated)) + `</span>`
+		lines = append(lines, `<p class="naverdict-related">`+label+` `+EscapeHTML(strings.Join(entry.Related, ", "))+`</p>`)
+	}
+	lines = append(lines, `</article>`)
+	return strings.Join(lines, "\n")
+}
+
+// Annotate the headword with Naver's romanisation, which has the sound
+// changes of its pronunciation, or with its hanja.
+func (renderer *RubyRenderer) headword(entry Entry) string {
+	annotation := entry.Romanisation
+	if renderer.Annotation == AnnotateHanja {
+		annotation = entry.Hanja
+	} else if annotation == "" {
+		annotation = Romanise(entry.Title)
+	}
+	return ruby(entry.Title, annotation)
+}
+
+// Escape text and annotate its Hangul words with their romanisation, or
+// the headword with its hanja where it starts a word alone or before a
+// particle.
+func (renderer *RubyRenderer) annotate(entry Entry, text string) string {
+	if renderer.Annotation == AnnotateHanja {
+		if entry.Hanja == "" {
+			return EscapeHTML(text)
+		}
+		var annotated strings.Builder
+		for len(text) > 0 {
+			if length := headwordat(entry.Title, text); length > 0 {
+				annotated.WriteString(ruby(entry.Title, entry.Hanja))
+				text = text[length:]
+			}
+			word := strings.IndexFunc(text, func(char rune) bool { return !ishangul(char) })
+			if word == 0 {
+				_, size := utf8.DecodeRuneInString(text)
+				word = size
+			} else if word < 0 {
+				word = len(text)
+			}
+			annotated.WriteString(EscapeHTML(text[:word]))
+			text = text[word:]
+		}
+		return annotated.String()
+	}
+
+	var annotated strings.Builder
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			annotated.WriteString(ruby(string(word), Romanise(string(word))))
+			word = word[:0]
+		}
+	}
+	for _, char := range text {
+		if unicode.Is(unicode.Hangul, char) {
+			word = append(word, char)
+			continue
+		}
+		flush()
+		annotated.WriteString(EscapeHTML(string(char)))
+	}
+	flush()
+	return annotated.String()
+}
+
+// Particles that may follow a Sino-Korean headword in a word, e.g. 을 of
+// 안녕을. Other syllables after it make another word, e.g. 사과 for 사.
+var particles = map[string]bool{
+	"이": true, "가": true, "은": true, "는": true, "을": true, "를": true,
+	"의": true, "에": true, "에서": true, "에게": true, "께": true, "한테": true,
+	"로": true, "으로": true, "와": true, "과": true, "도": true, "만": true,
+	"까지": true, "부터": true, "보다": true, "처럼": true, "이나": true, "나": true,
+	"하고": true, "이랑": true, "랑": true, "에는": true, "에도": true, "에서는": true,
+	"이다": true, "입니다": true, "이에요": true, "예요": true,
+}
+
+// Length in bytes of the headword at the start of a word of text, if the
+// rest of the word is empty or a particle, or 0.
+func headwordat(headword string, text string) int {
+	if headword == "" || !strings.HasPrefix(text, headword) {
+		return 0
+	}
+	rest := text[len(headword):]
+	end := strings.IndexFunc(rest, func(char rune) bool { return !ishangul(char) })
+	if end < 0 {
+		end = len(rest)
+	}
+	if end > 0 && !particles[rest[:end]] {
+		return 0
+	}
+	return len(headword)
+}
+
+// A <ruby> element, with parentheses for browsers without ruby support.
+func ruby(base string, annotation string) string {
+	if annotation == "" {
+		return EscapeHTML(base)
+	}
+	return "<ruby>" + EscapeHTML(base) + "<rp>(</rp><rt>" + EscapeHTML(annotation) + "</rt><rp>)</rp></ruby>"
+}
@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRubyRomanised(t *testing.T) {
+	got := RubyRomanised.Render(renderentry)
+	want := `<article class="naverdict" lang="ko">
+<h1 class="naverdict-headword"><ruby>안녕<rp>(</rp><rt>an-nyeong</rt><rp>)</rp></ruby></h1> <span class="naverdict-hanja">安寧</span> <span class="naverdict-topik" lang="en">TOPIK Elementary</span> <span class="naverdict-importance">★★★</span>
+<p class="naverdict-pronunciation">[안녕]</p>
+<ol class="naverdict-glosses" lang="en">
+<li>hello</li>
+<li>peace</li>
+</ol>
+<section class="naverdict-group">
+<h2 class="naverdict-part-of-speech">명사 <span lang="en">(noun)</span></h2>
+<ol class="naverdict-senses">
+<li value="1">
+<p class="naverdict-gloss" lang="en">peace</p>
+<p class="naverdict-definition" lang="en">The state of being at peace.</p>
+<ul class="naverdict-examples">
+<li><ruby>가족의<rp>(</rp><rt>gajokui</rt><rp>)</rp></ruby> <mark><ruby>안녕<rp>(</rp><rt>annyeong</rt><rp>)</rp></ruby></mark><ruby>을<rp>(</rp><rt>eul</rt><rp>)</rp></ruby> <ruby>빌다<rp>(</rp><rt>bilda</rt><rp>)</rp></ruby>.</li>
+</ul>
+</li>
+</ol>
+</section>
+<section class="naverdict-group">
+<h2 class="naverdict-part-of-speech">감탄사 <span lang="en">(interjection)</span></h2>
+<ol class="naverdict-senses">
+<li value="2">
+<p class="naverdict-gloss" lang="en">hi (informal)</p>
+<p class="naverdict-definition">인사말.</p>
+</li>
+</ol>
+</section>
+</article>`
+	if got != want {
+		t.Errorf("RubyRomanised.Render() = %q; want %q", got, want)
+	}
+	if got := RubyRomanised.Render(Entry{}); got != "" {
+		t.Errorf("RubyRomanised.Render(Entry{}) = %q; want empty string", got)
+	}
+}
+
+func TestRubyHanja(t *testing.T) {
+	got := RubyHanja.RenderIn(Korean, renderentry)
+	for _, want := range []string{
+		`<h1 class="naverdict-headword"><ruby>안녕<rp>(</rp><rt>安寧</rt><rp>)</rp></ruby></h1> <span class="naverdict-topik" lang="ko">TOPIK 초급</span>`,
+		`<li>가족의 <mark><ruby>안녕<rp>(</rp><rt>安寧</rt><rp>)</rp></ruby></mark>을 빌다.</li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RubyHanja.RenderIn(%q) = %q; want it to contain %q", Korean, got, want)
+		}
+	}
+	if strings.Contains(got, "naverdict-hanja") {
+		t.Errorf("RubyHanja.RenderIn(%q) = %q; want the hanja only as ruby", Korean, got)
+	}
+}
+
+func TestRubyHanjaWordStarts(t *testing.T) {
+	entry := Entry{Title: "사", Hanja: "四"}
+	tests := map[string]string{
+		"사과를 사요.":   "사과를 사요.",
+		"사에 일을 더하다": "<ruby>사<rp>(</rp><rt>四</rt><rp>)</rp></ruby>에 일을 더하다",
+		"삼, 사, 오":   "삼, <ruby>사<rp>(</rp><rt>四</rt><rp>)</rp></ruby>, 오",
+		"오사카":       "오사카",
+	}
+	for text, want := range tests {
+		if got := RubyHanja.annotate(entry, text); got != want {
+			t.Errorf("RubyHanja.annotate(%q) = %q; want %q", text, got, want)
+		}
+	}
+}
+
+func TestRubyEscape(t *testing.T) {
+	entry := Entry{Title: "<b>", Senses: []Sense{{Number: 1, Gloss: "a & b", Examples: []Markup{{Text: "x < 나"}}}}}
+	got := RubyRomanised.Render(entry)
+	for _, want := range []string{`<ruby>&lt;b&gt;<rp>(</rp><rt>&lt;b&gt;</rt><rp>)</rp></ruby>`, `a &amp; b`, `<li>x &lt; <ruby>나<rp>(</rp><rt>na</rt><rp>)</rp></ruby></li>`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RubyRomanised.Render() = %q; want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestGetRubyRenderer(t *testing.T) {
+	tests := map[string]*RubyRenderer{"": RubyRomanised, "romanization": RubyRomanised, "Hanja": RubyHanja}
+	for annotation, want := range tests {
+		got, errruby := GetRubyRenderer(annotation)
+		if got != want || errruby != nil {
+			t.Errorf("GetRubyRenderer(%q) = %v, %v; want %v", annotation, got, errruby, want)
+		}
+	}
+	if _, errruby := GetRubyRenderer("furigana"); errruby == nil {
+		t.Errorf("GetRubyRenderer(%q) = nil; want error", "furigana")
+	}
+}
@@ -0,0 +1,328 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Expected shape of the Naver search response (entryinfo), as paths to the
+// fields this package reads. A segment ending in [] is an array, and each
+// element is checked.
+var EntryInfoSchema = map[string]string{
+	"searchResultMap":                                          "object",
+	"searchResultMap.searchResultListMap":                      "object",
+	"searchResultMap.searchResultListMap.WORD":                 "object",
+	"searchResultMap.searchResultListMap.WORD.items[]":         "object",
+	"searchResultMap.searchResultListMap.WORD.items[].entryId": "string",
+}
+
+// Expected shape of the Naver entry response (searchinfo).
+var SearchInfoSchema = map[string]string{
+	"entry":                                    "object",
+	"entry.entry_level":                        "string",
+	"entry.entry_importance":                   "number",
+	"entry.primary_mean":                       "string",
+	"entry.members[]":                          "object",
+	"entry.members[].entry_name":               "string",
+	"entry.members[].origin_language":          "string",
+	"entry.members[].prons[]":                  "object",
+	"entry.members[].prons[].show_pron_symbol": "string",
+	"entry.means[]":                            "object",
+	"entry.means[].part":                       "object",
+	"entry.means[].part.part_ko_name":          "string",
+	"entry.means[].show_mean":                  "string",
+	"entry.means[].description_json":           "string",
+	"entry.means[].examples[]":                 "object",
+	"entry.means[].examples[].origin_example":  "string",
+}
+
+// UpstreamSchema is the expected shape of the responses of a Naver
+// dictionary.
+type UpstreamSchema struct {
+	EntryInfo  map[string]string
+	SearchInfo map[string]string
+}
+
+// Expected shapes by dictionary Code. Only koen is known, so the responses
+// of other dictionaries, which differ from it, are not checked.
+var UpstreamSchemas = map[string]UpstreamSchema{
+	"koen": {EntryInfoSchema, SearchInfoSchema},
+}
+
+// SchemaViolation is a field that does not match the expected shape.
+type SchemaViolation struct {
+	Path    string `json:"path"`
+	Problem string `json:"problem"`
+}
+
+// Get the JSON kind of a decoded value.
+func jsonkind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Check one field path against the data, appending any violation.
+func checkpath(value interface{}, segments []string, walked string, kind string, violations []SchemaViolation) []SchemaViolation {
+	if len(segments) == 0 {
+		if got := jsonkind(value); got != kind {
+			return append(violations, SchemaViolation{walked, fmt.Sprintf("expected %s, got %s", kind, got)})
+		}
+		return violations
+	}
+	object, isobject := value.(map[string]interface{})
+	if !isobject {
+		return violations // Reported against the parent path.
+	}
+	segment := segments[0]
+	name, isarray := strings.CutSuffix(segment, "[]")
+	path := strings.TrimPrefix(walked+"."+segment, ".")
+	field, found := object[name]
+	if !found {
+		return append(violations, SchemaViolation{path, "missing"})
+	}
+	if !isarray {
+		return checkpath(field, segments[1:], path, kind, violations)
+	}
+	items, isitems := field.([]interface{})
+	if !isitems {
+		return append(violations, SchemaViolation{path, fmt.Sprintf("expected array, got %s", jsonkind(field))})
+	}
+	// Report each path once per response, however many elements violate it.
+	for _, item := range items {
+		itemviolations := checkpath(item, segments[1:], path, kind, nil)
+		if len(itemviolations) > 0 {
+			return append(violations, itemviolations[0])
+		}
+	}
+	return violations
+}
+
+// Validate decoded JSON against a schema of field paths.
+func ValidateSchema(data map[string]interface{}, schema map[string]string) []SchemaViolation {
+	paths := make([]string, 0, len(schema))
+	for path := range schema {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	violations := []SchemaViolation{}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		for _, violation := range checkpath(data, strings.Split(path, "."), "", schema[path], nil) {
+			if !seen[violation.Path] {
+				seen[violation.Path] = true
+				violations = append(violations, violation)
+			}
+		}
+	}
+	return violations
+}
+
+// SchemaAlerter is notified when the drift rate crosses the threshold.
+type SchemaAlerter interface {
+	Alert(message string) error
+}
+
+// SchemaReport is a snapshot of the schema checks so far.
+type SchemaReport struct {
+	Checked    map[string]int       `json:"checked"`    // Responses checked, by response kind.
+	Drifted    map[string]int       `json:"drifted"`    // Responses with at least one violation, by response kind.
+	Violations map[string]int       `json:"violations"` // Violations by field path, prefixed with the response kind.
+	Problems   map[string]string    `json:"problems"`   // Latest problem by field path.
+	Recent     map[string]float64   `json:"recent"`     // Drift rate over the recent window, by response kind.
+	LastSeen   map[string]time.Time `json:"last_seen"`  // Latest violation by field path.
+
+	AlertFailures  int    `json:"alert_failures"`             // Alerts the Alerter failed to send.
+	LastAlertError string `json:"last_alert_error,omitempty"` // Error of the latest failed alert.
+}
+
+// SchemaMonitor counts schema violations of upstream responses.
+type SchemaMonitor struct {
+	mu         sync.Mutex
+	checked    map[string]int
+	drifted    map[string]int
+	violations map[string]int
+	problems   map[string]string
+	lastseen   map[string]time.Time
+	window     map[string][]bool // Whether each recent response drifted, by response kind.
+
+	Window    int           // Number of recent responses the drift rate is computed over.
+	Threshold float64       // Drift rate that triggers an alert. Zero disables alerts.
+	Cooldown  time.Duration // Minimum time between alerts.
+	Alerter   SchemaAlerter
+	alerted   time.Time
+
+	alertfailures  int
+	lastalerterror string
+}
+
+// Create a SchemaMonitor without alerts.
+func NewSchemaMonitor() *SchemaMonitor {
+	return &SchemaMonitor{
+		checked:    map[string]int{},
+		drifted:    map[string]int{},
+		violations: map[string]int{},
+		problems:   map[string]string{},
+		lastseen:   map[string]time.Time{},
+		window:     map[string][]bool{},
+		Window:     100,
+		Cooldown:   time.Hour,
+	}
+}
+
+// Monitor used by the Naver providers.
+var DefaultSchemaMonitor = NewSchemaMonitor()
+
+func init() {
+	expvar.Publish("naverdict_schema", expvar.Func(func() interface{} {
+		return DefaultSchemaMonitor.Report()
+	}))
+}
+
+// Check a response of the given kind (e.g. koen/entryinfo) and record its
+// violations. Each kind has its own drift window.
+func (monitor *SchemaMonitor) Check(kind string, data map[string]interface{}, schema map[string]string) []SchemaViolation {
+	violations := ValidateSchema(data, schema)
+	now := time.Now()
+
+	monitor.mu.Lock()
+	monitor.checked[kind]++
+	if len(violations) > 0 {
+		monitor.drifted[kind]++
+	}
+	for _, violation := range violations {
+		path := kind + ":" + violation.Path
+		monitor.violations[path]++
+		monitor.problems[path] = violation.Problem
+		monitor.lastseen[path] = now
+	}
+	window := append(monitor.window[kind], len(violations) > 0)
+	if len(window) > monitor.Window {
+		window = window[len(window)-monitor.Window:]
+	}
+	monitor.window[kind] = window
+	rate := driftrate(window)
+
+	// Only alert once the window is full, so a single bad response does not page anyone.
+	var alert string
+	if monitor.Alerter != nil && monitor.Threshold > 0 && len(window) >= monitor.Window &&
+		rate >= monitor.Threshold && now.Sub(monitor.alerted) >= monitor.Cooldown {
+		monitor.alerted = now
+		alert = fmt.Sprintf("NaverDict schema drift: %.0f%% of the last %d %s responses do not match the expected shape.",
+			rate*100, len(window), kind)
+		for _, violation := range violations {
+			alert += fmt.Sprintf("\n%s: %s", violation.Path, violation.Problem)
+		}
+	}
+	alerter := monitor.Alerter
+	monitor.mu.Unlock()
+
+	if alert != "" {
+		go monitor.sendalert(alerter, alert) // Never hold up a lookup on the notification.
+	}
+	return violations
+}
+
+// Send an alert, logging and counting a failure, e.g. a wrong bot token.
+func (monitor *SchemaMonitor) sendalert(alerter SchemaAlerter, alert string) {
+	erralert := alerter.Alert(alert)
+	if erralert == nil {
+		return
+	}
+	log.Printf("cannot send schema drift alert: %v", erralert)
+	monitor.mu.Lock()
+	monitor.alertfailures++
+	monitor.lastalerterror = erralert.Error()
+	monitor.mu.Unlock()
+}
+
+// Fraction of responses in the window that drifted.
+func driftrate(window []bool) float64 {
+	if len(window) == 0 {
+		return 0
+	}
+	drifted := 0
+	for _, drift := range window {
+		if drift {
+			drifted++
+		}
+	}
+	return float64(drifted) / float64(len(window))
+}
+
+// Take a snapshot of the checks so far.
+func (monitor *SchemaMonitor) Report() SchemaReport {
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+	report := SchemaReport{
+		Checked:    map[string]int{},
+		Drifted:    map[string]int{},
+		Violations: map[string]int{},
+		Problems:   map[string]string{},
+		Recent:     map[string]float64{},
+		LastSeen:   map[string]time.Time{},
+
+		AlertFailures:  monitor.alertfailures,
+		LastAlertError: monitor.lastalerterror,
+	}
+	for kind, count := range monitor.checked {
+		report.Checked[kind] = count
+		report.Drifted[kind] = monitor.drifted[kind]
+		report.Recent[kind] = driftrate(monitor.window[kind])
+	}
+	for path, count := range monitor.violations {
+		report.Violations[path] = count
+		report.Problems[path] = monitor.problems[path]
+		report.LastSeen[path] = monitor.lastseen[path]
+	}
+	return report
+}
+
+// TelegramAlerter sends alerts to a Telegram chat, e.g. an admin group.
+type TelegramAlerter struct {
+	Token  string // Bot token from @BotFather.
+	ChatId string
+}
+
+// Send an alert with the Telegram Bot API.
+func (alerter *TelegramAlerter) Alert(message string) error {
+	body, errmarshal := json.Marshal(map[string]string{"chat_id": alerter.ChatId, "text": message})
+	if errmarshal != nil {
+		return errmarshal
+	}
+	sendurl := "https://api.telegram.org/bot" + url.PathEscape(alerter.Token) + "/sendMessage"
+	resp, errpost := http.Post(sendurl, "application/json", bytes.NewReader(body))
+	if errpost != nil {
+		msg := fmt.Sprintf("cannot send Telegram alert: %v", errpost)
+		return errors.New(msg)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg := fmt.Sprintf("unexpected Telegram status: %s", resp.Status)
+		return errors.New(msg)
+	}
+	return nil
+}
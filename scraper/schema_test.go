@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateSchemaExample(t *testing.T) {
+	violations := ValidateSchema(examplesearchinfo, SearchInfoSchema)
+	if len(violations) != 0 {
+		t.Errorf("ValidateSchema(%v) = %v; want no violations", examplesearchinfo, violations)
+	}
+}
+
+func TestValidateSchemaDrift(t *testing.T) {
+	searchinfo := map[string]interface{}{
+		"entry": map[string]interface{}{
+			"entry_level":      "1",
+			"entry_importance": "2", // Now a string.
+			"primary_mean":     "puppy",
+			"members": []interface{}{
+				map[string]interface{}{"entry_name": "강아지", "origin_language": "", "prons": []interface{}{}},
+				map[string]interface{}{"entryName": "강아지", "origin_language": ""}, // Renamed.
+			},
+			"means": []interface{}{},
+		},
+	}
+	want := []SchemaViolation{
+		{"entry.entry_importance", "expected number, got string"},
+		{"entry.members[].entry_name", "missing"},
+		{"entry.members[].prons[]", "missing"},
+	}
+	got := ValidateSchema(searchinfo, SearchInfoSchema)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateSchema(%v) = %v; want %v", searchinfo, got, want)
+	}
+}
+
+func TestValidateSchemaMissingRoot(t *testing.T) {
+	want := []SchemaViolation{{"searchResultMap", "missing"}}
+	got := ValidateSchema(map[string]interface{}{}, EntryInfoSchema)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateSchema({}) = %v; want %v", got, want)
+	}
+}
+
+// recordingalerter stores the alerts it is sent.
+type recordingalerter struct {
+	sync.WaitGroup
+	alerts []string
+	err    error // Returned by every Alert.
+}
+
+func (alerter *recordingalerter) Alert(message string) error {
+	alerter.alerts = append(alerter.alerts, message)
+	alerter.Done()
+	return alerter.err
+}
+
+func TestSchemaMonitorAlert(t *testing.T) {
+	alerter := &recordingalerter{}
+	monitor := NewSchemaMonitor()
+	monitor.Window = 4
+	monitor.Threshold = 0.5
+	monitor.Alerter = alerter
+
+	alerter.Add(1)
+	monitor.Check("searchinfo", examplesearchinfo, SearchInfoSchema)
+	monitor.Check("searchinfo", map[string]interface{}{}, SearchInfoSchema)
+	monitor.Check("searchinfo", examplesearchinfo, SearchInfoSchema)
+	monitor.Check("searchinfo", map[string]interface{}{}, SearchInfoSchema) // Window full, 50% drift.
+	monitor.Check("searchinfo", map[string]interface{}{}, SearchInfoSchema) // Within cooldown.
+	alerter.Wait()
+
+	if len(alerter.alerts) != 1 {
+		t.Errorf("Alert() called %d times; want 1", len(alerter.alerts))
+	}
+	report := monitor.Report()
+	if report.Checked["searchinfo"] != 5 || report.Drifted["searchinfo"] != 3 || report.Violations["searchinfo:entry"] != 3 {
+		t.Errorf("Report() = %+v; want 5 checked, 3 drifted", report)
+	}
+}
+
+func TestSchemaMonitorAlertFailure(t *testing.T) {
+	alerter := &recordingalerter{err: errors.New("401 Unauthorized")}
+	monitor := NewSchemaMonitor()
+	monitor.Window = 1
+	monitor.Threshold = 0.5
+	monitor.Alerter = alerter
+
+	alerter.Add(1)
+	monitor.Check("searchinfo", map[string]interface{}{}, SearchInfoSchema)
+	alerter.Wait()
+
+	// The failure is recorded just after Alert returns.
+	deadline := time.Now().Add(time.Second)
+	report := monitor.Report()
+	for report.AlertFailures == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		report = monitor.Report()
+	}
+	if report.AlertFailures != 1 || report.LastAlertError != "401 Unauthorized" {
+		t.Errorf("Report() = %d failures, %q; want 1 failure, %q", report.AlertFailures, report.LastAlertError, "401 Unauthorized")
+	}
+}
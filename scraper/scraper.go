@@ -9,46 +9,64 @@ import (
 
 // Scrape TOPIK level
 func GetTopik(searchinfo map[string]interface{}) (string, error) {
+	level, errlevel := GetTopikLevel(searchinfo)
+	if errlevel != nil {
+		return "", errlevel
+	}
+	return level.Label(), nil
+}
+
+// Scrape TOPIK level as a number
+func GetTopikLevel(searchinfo map[string]interface{}) (TopikLevel, error) {
 	// Equivalent to searchInfo.entry.entry_level ?? ""
 	entry, errorentry := searchinfo["entry"].(map[string]interface{})
 	if !errorentry {
-		return "", errors.New("Cannot find searchResultMap in searchinfo")
+		return TopikNone, errors.New("Cannot find searchResultMap in searchinfo")
 	}
 	entrylevel, errorentrylevel := entry["entry_level"].(string)
 	if !errorentrylevel {
-		return "", errors.New("Cannot find entryLevel in entry")
+		return TopikNone, errors.New("Cannot find entryLevel in entry")
 	}
 
 	containsone := strings.Contains(entrylevel, "1")
 	if containsone {
-		return "(TOPIK Elementary)", nil
+		return TopikElementary, nil
 	}
 	containstwo := strings.Contains(entrylevel, "2")
 	if containstwo {
-		return "(TOPIK Intermediate)", nil
+		return TopikIntermediate, nil
 	}
-	return "", nil
+	return TopikNone, nil
 }
 
 // Scrape Importance Stars
 func GetImportance(searchinfo map[string]interface{}) (string, error) {
+	entryimportance, errimportance := GetImportanceLevel(searchinfo)
+	if errimportance != nil {
+		return "", errimportance
+	}
+	stars := strings.Repeat("★", entryimportance)
+	return stars, nil
+}
+
+// Scrape Importance as a number of stars
+func GetImportanceLevel(searchinfo map[string]interface{}) (int, error) {
 	// Importance is ranked from 0-3 stars.
 	// Equivalent to searchInfo.entry.entry_importance ?? 0
 	// BEWARE: numstars is a float64, not an int.
 	entry, errorentry := searchinfo["entry"].(map[string]interface{})
 	if !errorentry {
-		return "", errors.New("Cannot find searchResultMap in searchinfo")
+		return 0, errors.New("Cannot find searchResultMap in searchinfo")
 	}
 	entryimportancefloat, errorentryimportance := entry["entry_importance"].(float64)
 	if !errorentryimportance {
-		return "", errors.New("Cannot find entryImportance in entry")
+		return 0, errors.New("Cannot find entryImportance in entry")
 	}
 	entryimportance := int(entryimportancefloat)
 	if entryimportance < 0 || entryimportance > 3 {
-		return "", errors.New("Importance is out of range.")
+		return 0, errors.New("Importance is out of range.")
 	}
-	stars := strings.Repeat("★", entryimportance)
-	return stars, nil
+	return entryimportance, nil
 }
 
 // Scrape Title
@@ -107,7 +125,7 @@ func GetEnDef(searchinfo map[string]interface{}) (string, error) {
 		return "", errors.New("Cannot find primaryMean in entry")
 	}
 
-	endefs := strings.Split(primarymean, `|||`)
+	endefs := strings.Split(StripMarkup(primarymean), `|||`)
 	endefsNumbered := make([]string, len(endefs))
 	for i, def := range endefs {
 		endefsNumbered[i] = fmt.Sprintf("%d.%s", i+1, def)
@@ -193,7 +211,7 @@ func GetMeaning(meaningitem map[string]interface{}, idx int) (string, error) {
 	if !errormeaning {
 		return "", errors.New("Cannot find ShowMean in meaningitem")
 	}
-	meaningstr := fmt.Sprintf("%d.%s", idx+1, meaning)
+	meaningstr := fmt.Sprintf("%d.%s", idx+1, StripMarkup(meaning))
 
 	// Step 2. Get English and Korean Description
 	descitem, errordescitem := meaningitem["description_json"].(string)
@@ -232,10 +250,10 @@ func GetMeaning(meaningitem map[string]interface{}, idx int) (string, error) {
 	if !errororiginexample {
 		return "", errors.New("Cannot find OriginExample in example")
 	}
-	examplestr := fmt.Sprintf("|| %s", originexample)
+	examplestr := fmt.Sprintf("|| %s", StripMarkup(originexample))
 
 	// Step 4. Combine Meaning, Description, and Example
-	description := fmt.Sprintf("%s\n%s\n%s\n%s", meaningstr, enstr, kostr, examplestr)
+	description := fmt.Sprintf("%s\n%s\n%s\n%s", meaningstr, StripMarkup(enstr), StripMarkup(kostr), examplestr)
 	return description, nil
 }
 
@@ -265,38 +283,9 @@ func GetMeanings(searchinfo map[string]interface{}) (string, error) {
 
 // Scrape Dictionary
 func Scrape(searchinfo map[string]interface{}) (DictInfo, error) {
-	topik, errortopik := GetTopik(searchinfo)
-	if errortopik != nil {
-		topik = ""
-	}
-	importance, errorimportance := GetImportance(searchinfo)
-	if errorimportance != nil {
-		importance = ""
-	}
-	title, errortitle := GetTitle(searchinfo)
-	if errortitle != nil {
-		title = ""
-	}
-	hanja, errorhanja := GetHanja(searchinfo)
-	if errorhanja != nil {
-		hanja = ""
-	}
-	endef, errorendef := GetEnDef(searchinfo)
-	if errorendef != nil {
-		endef = ""
-	}
-	pronun, errorpronun := GetPronun(searchinfo)
-	if errorpronun != nil {
-		pronun = ""
-	}
-	partspeech, errpartspeech := GetPartSpeech(searchinfo)
-	if errpartspeech != nil {
-		partspeech = ""
-	}
-	meanings, errmeanings := GetMeanings(searchinfo)
-	if errmeanings != nil {
-		meanings = ""
+	entry, errentry := ScrapeEntry(searchinfo)
+	if errentry != nil {
+		return DictInfo{}, errentry
 	}
-	dictinfo := DictInfo{topik, importance, title, hanja, endef, pronun, partspeech, meanings}
-	return dictinfo, nil
+	return entry.DictInfo(), nil
 }
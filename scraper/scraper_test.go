@@ -44,6 +44,10 @@ var examplesearchinfo = map[string]interface{}{
 				},
 			},
 		},
+		"related_words": []interface{}{
+			map[string]interface{}{"entry_name": "개"},
+			map[string]interface{}{"entry_name": "<strong>멍멍이</strong>"},
+		},
 	},
 }
 
@@ -164,6 +168,21 @@ func TestGetTitleExample(t *testing.T) {
 	}
 }
 
+func TestGetId(t *testing.T) {
+	searchinfo := map[string]interface{}{"entry": map[string]interface{}{"entry_id": "8ad4bd1cbb1f4e9bb1e5e5d1d5cdb5b5"}}
+	id, error := GetId(searchinfo)
+	if error != nil {
+		t.Errorf("GetId(%q) = %q; want no error", searchinfo, error)
+	}
+	if id != "8ad4bd1cbb1f4e9bb1e5e5d1d5cdb5b5" {
+		t.Errorf("Expected 8ad4bd1cbb1f4e9bb1e5e5d1d5cdb5b5, got %s", id)
+	}
+	_, error = GetId(examplesearchinfo)
+	if error == nil {
+		t.Errorf("GetId(%q) = nil; want error", examplesearchinfo)
+	}
+}
+
 func TestGetHanjaExample(t *testing.T) {
 	hanja, error := GetHanja(examplesearchinfo)
 	if error != nil {
@@ -225,6 +244,9 @@ func TestScrapeExample(t *testing.T) {
 		Pronun:     "[gang-a-ji] [강아지]",
 		Partspeech: "명사",
 		Meanings:   "1.강아지\na puppy or young dog\n어린 개\n|| 강아지가 귀엽다",
+
+		TopikLevel:      TopikElementary,
+		ImportanceLevel: 2,
 	}
 	if error != nil {
 		t.Errorf("Scrape(%q) = %q; want no error", scraped, error)
@@ -233,3 +255,60 @@ func TestScrapeExample(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, scraped)
 	}
 }
+
+func TestGetTopikLevel(t *testing.T) {
+	tests := map[string]TopikLevel{
+		"":  TopikNone,
+		"1": TopikElementary,
+		"2": TopikIntermediate,
+	}
+	for entrylevel, want := range tests {
+		searchinfo := map[string]interface{}{
+			"entry": map[string]interface{}{"entry_level": entrylevel},
+		}
+		got, errlevel := GetTopikLevel(searchinfo)
+		if errlevel != nil || got != want {
+			t.Errorf("GetTopikLevel(%q) = %v, %v; want %v", entrylevel, got, errlevel, want)
+		}
+	}
+}
+
+func TestParseTopikLevel(t *testing.T) {
+	tests := map[string]TopikLevel{
+		"0":            TopikNone,
+		"1":            TopikElementary,
+		"Elementary":   TopikElementary,
+		" 2 ":          TopikIntermediate,
+		"intermediate": TopikIntermediate,
+	}
+	for level, want := range tests {
+		got, errparse := ParseTopikLevel(level)
+		if errparse != nil || got != want {
+			t.Errorf("ParseTopikLevel(%q) = %v, %v; want %v", level, got, errparse, want)
+		}
+	}
+	_, errparse := ParseTopikLevel("advanced")
+	if errparse == nil {
+		t.Errorf("ParseTopikLevel(%q) = nil; want error", "advanced")
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	dictinfo := DictInfo{TopikLevel: TopikIntermediate, ImportanceLevel: 2}
+	tests := []struct {
+		filter Filter
+		want   bool
+	}{
+		{Filter{}, true},
+		{Filter{Levels: []TopikLevel{TopikIntermediate}}, true},
+		{Filter{Levels: []TopikLevel{TopikNone, TopikElementary}}, false},
+		{Filter{MinImportance: 2}, true},
+		{Filter{MinImportance: 3}, false},
+	}
+	for _, test := range tests {
+		got := test.filter.Matches(dictinfo)
+		if got != test.want {
+			t.Errorf("%+v.Matches(%+v) = %v; want %v", test.filter, dictinfo, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package scrapertest
+
+import (
+	"context"
+	"strings"
+)
+
+type faultkey struct{}
+
+// Store the faults of a request, for respond.
+func withfaults(ctx context.Context, faults []Fault) context.Context {
+	return context.WithValue(ctx, faultkey{}, faults)
+}
+
+// Get the faults of a request.
+func faultsof(ctx context.Context) []Fault {
+	faults, _ := ctx.Value(faultkey{}).([]Fault)
+	return faults
+}
+
+// Walk to the objects holding the last segment of a dotted path, e.g.
+// entry.means[].show_mean, where a segment ending in [] is an array.
+func parents(value interface{}, segments []string) []map[string]interface{} {
+	object, isobject := value.(map[string]interface{})
+	if !isobject {
+		return nil
+	}
+	if len(segments) == 1 {
+		return []map[string]interface{}{object}
+	}
+	name, isarray := strings.CutSuffix(segments[0], "[]")
+	if !isarray {
+		return parents(object[name], segments[1:])
+	}
+	items, _ := object[name].([]interface{})
+	found := []map[string]interface{}{}
+	for _, item := range items {
+		found = append(found, parents(item, segments[1:])...)
+	}
+	return found
+}
+
+// RemoveField returns a Fault Mutate function that deletes a field, e.g.
+// RemoveField("entry.means[].show_mean").
+func RemoveField(path string) func(map[string]interface{}) {
+	segments := strings.Split(path, ".")
+	last := segments[len(segments)-1]
+	return func(response map[string]interface{}) {
+		for _, parent := range parents(response, segments) {
+			delete(parent, last)
+		}
+	}
+}
+
+// RenameField returns a Fault Mutate function that renames a field, e.g.
+// RenameField("entry.members[].entry_name", "entryName").
+func RenameField(path string, name string) func(map[string]interface{}) {
+	segments := strings.Split(path, ".")
+	last := segments[len(segments)-1]
+	return func(response map[string]interface{}) {
+		for _, parent := range parents(response, segments) {
+			value, found := parent[last]
+			if found {
+				delete(parent, last)
+				parent[name] = value
+			}
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Package scrapertest provides a fake Naver Dictionary server, for testing
+// code built on the scraper package without network access.
+//
+//	server := scrapertest.NewServer()
+//	defer server.Close()
+//	dictinfo, err := scraper.GetFrom(server.Provider(), "사랑")
+//
+// The server answers the Korean-English search (/api3/koen/search), entry
+// (/api/platform/koen/entry) and autocomplete (/koen/ac) APIs from a set of
+// bundled words (see Words), and faults can be injected with Inject.
+package scrapertest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"naverdictionary/scraper"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed words/*.json
+var wordfiles embed.FS
+
+// Word is a bundled dictionary entry.
+type Word struct {
+	Queries    []string               `json:"queries"` // Search terms that find the word, e.g. misspellings.
+	EntryId    string                 `json:"entry_id"`
+	SearchInfo map[string]interface{} `json:"searchinfo"` // Entry response, as returned by Naver.
+}
+
+// Load the bundled words.
+func loadwords() ([]Word, error) {
+	files, errglob := wordfiles.ReadDir("words")
+	if errglob != nil {
+		return nil, errglob
+	}
+	words := make([]Word, 0, len(files))
+	for _, file := range files {
+		data, errread := wordfiles.ReadFile(path.Join("words", file.Name()))
+		if errread != nil {
+			return nil, errread
+		}
+		var word Word
+		errdecode := json.Unmarshal(data, &word)
+		if errdecode != nil {
+			return nil, fmt.Errorf("cannot decode %s: %v", file.Name(), errdecode)
+		}
+		words = append(words, word)
+	}
+	return words, nil
+}
+
+// Words returns the headwords of the bundled words, sorted.
+func Words() []string {
+	words, errwords := loadwords()
+	if errwords != nil {
+		panic(errwords) // The words are embedded, so this is a bug in the package.
+	}
+	headwords := make([]string, len(words))
+	for i, word := range words {
+		headwords[i] = word.Queries[0]
+	}
+	sort.Strings(headwords)
+	return headwords
+}
+
+// Fault changes how the server answers matching requests.
+type Fault struct {
+	Path      string        // Only affects requests to this path, e.g. /api3/koen/search. Empty affects all paths.
+	Times     int           // Number of requests affected. Zero affects every request until Reset.
+	Latency   time.Duration // Delay before answering.
+	Status    int           // Answer with this status instead, e.g. http.StatusTooManyRequests.
+	Malformed bool          // Answer with a body that is not valid JSON.
+	// Change the response before it is sent, e.g. to emulate a schema change.
+	// See RemoveField and RenameField.
+	Mutate func(response map[string]interface{})
+}
+
+// Server is a fake Naver Dictionary server.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	words    map[string]Word // By query.
+	entries  map[string]Word // By entry id.
+	faults   []*Fault
+	requests map[string]int // By path.
+}
+
+// Start a fake Naver Dictionary server with the bundled words.
+// The caller should call Close when finished.
+func NewServer() *Server {
+	words, errwords := loadwords()
+	if errwords != nil {
+		panic(errwords)
+	}
+	server := &Server{
+		words:    map[string]Word{},
+		entries:  map[string]Word{},
+		requests: map[string]int{},
+	}
+	for _, word := range words {
+		server.AddWord(word)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api3/koen/search", server.search)
+	mux.HandleFunc("/api/platform/koen/entry", server.entry)
+	mux.HandleFunc("/koen/ac", server.autocomplete)
+	server.Server = httptest.NewServer(server.faulty(mux))
+	return server
+}
+
+// Add a word, or replace a bundled one.
+func (server *Server) AddWord(word Word) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, query := range word.Queries {
+		server.words[query] = word
+	}
+	server.entries[word.EntryId] = word
+}
+
+// Inject a fault. Faults are applied in the order they were injected.
+func (server *Server) Inject(fault Fault) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.faults = append(server.faults, &fault)
+}
+
+// Remove all faults.
+func (server *Server) Reset() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.faults = nil
+}
+
+// Number of requests received for a path, e.g. /api3/koen/search.
+func (server *Server) Requests(path string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.requests[path]
+}
+
+// Client returns a scraper Client that sends every request to the server.
+func (server *Server) Client() *scraper.Client {
+	client, errclient := scraper.NewClient(scraper.WithBaseUrl(server.URL))
+	if errclient != nil {
+		panic(errclient)
+	}
+	return client
+}
+
+// Provider returns a Korean-English provider that looks words up on the server.
+func (server *Server) Provider() *scraper.NaverProvider {
+	return scraper.KoreanEnglish.WithClient(server.Client())
+}
+
+// Install sends every request of scraper.DefaultClient to the server, until
+// the returned function is called.
+func (server *Server) Install() (restore func()) {
+	previous := scraper.DefaultClient.BaseUrl
+	scraper.DefaultClient.BaseUrl = server.Client().BaseUrl
+	return func() {
+		scraper.DefaultClient.BaseUrl = previous
+	}
+}
+
+// Get the faults affecting a request to path, using up their Times.
+func (server *Server) matchfaults(path string) []Fault {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.requests[path]++
+	matched := []Fault{}
+	remaining := server.faults[:0]
+	for _, fault := range server.faults {
+		if fault.Path != "" && fault.Path != path {
+			remaining = append(remaining, fault)
+			continue
+		}
+		matched = append(matched, *fault)
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				continue
+			}
+		}
+		remaining = append(remaining, fault)
+	}
+	server.faults = remaining
+	return matched
+}
+
+// Apply latency and status faults before handling a request. Body faults
+// are applied by respond.
+func (server *Server) faulty(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		faults := server.matchfaults(r.URL.Path)
+		for _, fault := range faults {
+			if fault.Latency > 0 {
+				select {
+				case <-time.After(fault.Latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if fault.Status != 0 && fault.Status != http.StatusOK {
+				if fault.Status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "1")
+				}
+				http.Error(w, http.StatusText(fault.Status), fault.Status)
+				return
+			}
+		}
+		r = r.WithContext(withfaults(r.Context(), faults))
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Send a JSON response, applying body faults.
+func respond(w http.ResponseWriter, r *http.Request, response map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	for _, fault := range faultsof(r.Context()) {
+		if fault.Mutate != nil {
+			fault.Mutate(response)
+		}
+		if fault.Malformed {
+			fmt.Fprint(w, `{"searchResultMap": {"searchResultListMap": `)
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Answer the search API: /api3/koen/search?query=
+func (server *Server) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	server.mu.Lock()
+	word, found := server.words[query]
+	server.mu.Unlock()
+
+	items := []interface{}{}
+	if found {
+		items = append(items, map[string]interface{}{
+			"entryId":  word.EntryId,
+			"expEntry": word.Queries[0],
+		})
+	}
+	respond(w, r, map[string]interface{}{
+		"query": query,
+		"searchResultMap": map[string]interface{}{
+			"searchResultListMap": map[string]interface{}{
+				"WORD": map[string]interface{}{
+					"query": query,
+					"total": len(items),
+					"items": items,
+				},
+			},
+		},
+	})
+}
+
+// Answer the entry API: /api/platform/koen/entry?entryId=
+func (server *Server) entry(w http.ResponseWriter, r *http.Request) {
+	entryid := r.URL.Query().Get("entryId")
+	server.mu.Lock()
+	word, found := server.entries[entryid]
+	server.mu.Unlock()
+	if !found {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	respond(w, r, copyjson(word.SearchInfo))
+}
+
+// Answer the autocomplete API: /koen/ac?q=
+func (server *Server) autocomplete(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	server.mu.Lock()
+	headwords := []string{}
+	for _, word := range server.entries {
+		if strings.HasPrefix(scraper.Decompose(word.Queries[0]), scraper.Decompose(query)) {
+			headwords = append(headwords, word.Queries[0])
+		}
+	}
+	server.mu.Unlock()
+	sort.Strings(headwords)
+
+	items := []interface{}{}
+	for _, headword := range headwords {
+		items = append(items, []interface{}{[]interface{}{headword}, []interface{}{""}})
+	}
+	respond(w, r, map[string]interface{}{
+		"query": []interface{}{query},
+		"items": []interface{}{items},
+	})
+}
+
+// Deep copy decoded JSON, so that faults cannot change the bundled words.
+func copyjson(value map[string]interface{}) map[string]interface{} {
+	data, _ := json.Marshal(value)
+	var copied map[string]interface{}
+	json.Unmarshal(data, &copied)
+	return copied
+}
@@ -0,0 +1,150 @@
+package scrapertest
+
+import (
+	"naverdictionary/scraper"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServerGet(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	for _, word := range Words() {
+		got, error := scraper.GetFrom(server.Provider(), word)
+		if error != nil {
+			t.Errorf("GetFrom(%q) = %q; want no error", word, error)
+		}
+		if got.Title != word {
+			t.Errorf("GetFrom(%q) = %+v; want title %q", word, got, word)
+		}
+	}
+}
+
+func TestServerMisspelling(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	got, error := scraper.GetFrom(server.Provider(), "저출새")
+	if error != nil || got.Title != "저출생" {
+		t.Errorf("GetFrom(%q) = %+v, %v; want 저출생", "저출새", got, error)
+	}
+}
+
+func TestServerOfflineFallback(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	index := scraper.NewOfflineIndex()
+	index.Add(scraper.OfflineEntry{Id: "krdict-1", Headword: "나무", PartOfSpeech: "명사", Senses: []scraper.OfflineSense{{EnglishLemma: "tree"}}})
+	provider := &scraper.FallbackProvider{Primary: server.Provider(), Fallback: &scraper.OfflineProvider{Index: index}}
+
+	got, error := scraper.GetFrom(provider, "나무")
+	if error != nil || got.Title != "나무" {
+		t.Errorf("GetFrom(fallback, %q) = %+v, %v; want 나무 from the offline index", "나무", got, error)
+	}
+	// The offline entry id is not sent to Naver.
+	if searches, entries := server.Requests("/api3/koen/search"), server.Requests("/api/platform/koen/entry"); searches != 1 || entries != 0 {
+		t.Errorf("GetFrom(fallback, %q) sent %d searches and %d entry requests; want 1 and 0", "나무", searches, entries)
+	}
+}
+
+func TestServerUnknownWord(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	_, error := scraper.GetFrom(server.Provider(), "없는말")
+	if error == nil {
+		t.Errorf("GetFrom(%q) = nil; want error", "없는말")
+	}
+}
+
+func TestServerInstall(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	restore := server.Install()
+	defer restore()
+
+	got, error := scraper.Get("학교")
+	if error != nil || got.Hanja != "學校" {
+		t.Errorf("Get(%q) = %+v, %v; want 學校", "학교", got, error)
+	}
+	suggestions, errsuggest := scraper.GetSuggestInfo("ㄱ")
+	if errsuggest != nil || len(suggestions) != 2 {
+		t.Errorf("GetSuggestInfo(%q) = %q, %v; want 강아지 and 공부", "ㄱ", suggestions, errsuggest)
+	}
+}
+
+func TestServerTooManyRequests(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Inject(Fault{Path: "/api3/koen/search", Times: 1, Status: http.StatusTooManyRequests})
+
+	_, error := scraper.GetFrom(server.Provider(), "사랑")
+	if error == nil {
+		t.Errorf("GetFrom(%q) with 429 = nil; want error", "사랑")
+	}
+	_, error = scraper.GetFrom(server.Provider(), "사랑")
+	if error != nil {
+		t.Errorf("GetFrom(%q) after 429 = %q; want no error", "사랑", error)
+	}
+	if server.Requests("/api3/koen/search") != 2 {
+		t.Errorf("Requests() = %d; want 2", server.Requests("/api3/koen/search"))
+	}
+}
+
+func TestServerMalformed(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Inject(Fault{Malformed: true})
+
+	_, error := scraper.GetFrom(server.Provider(), "사랑")
+	if error == nil {
+		t.Errorf("GetFrom(%q) with malformed JSON = nil; want error", "사랑")
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Inject(Fault{Path: "/api/platform/koen/entry", Latency: 50 * time.Millisecond})
+
+	start := time.Now()
+	scraper.GetFrom(server.Provider(), "사랑")
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("GetFrom(%q) took %v; want at least 50ms", "사랑", elapsed)
+	}
+}
+
+func TestServerSchemaChange(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Inject(Fault{Mutate: RenameField("entry.members[].entry_name", "entryName")})
+	server.Inject(Fault{Mutate: RemoveField("entry.primary_mean")})
+
+	got, error := scraper.GetFrom(server.Provider(), "사랑")
+	if error != nil {
+		t.Fatalf("GetFrom(%q) = %q; want no error", "사랑", error)
+	}
+	if got.Title != "" || got.Endef != "" || got.Pronun == "" {
+		t.Errorf("GetFrom(%q) = %+v; want blank title and English definition", "사랑", got)
+	}
+	server.Reset()
+	got, _ = scraper.GetFrom(server.Provider(), "사랑")
+	if got.Title != "사랑" {
+		t.Errorf("GetFrom(%q) after Reset = %+v; want title 사랑", "사랑", got)
+	}
+}
+
+func TestServerPartsOfSpeech(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	entry, errentry := scraper.GetEntryFrom(server.Provider(), "안녕")
+	if errentry != nil {
+		t.Fatalf("GetEntryFrom(server, %q) = %q; want no error", "안녕", errentry)
+	}
+	if len(entry.Groups) != 2 || entry.Groups[1].PartOfSpeechEnglish != "interjection" {
+		t.Errorf("GetEntryFrom(server, %q).Groups = %+v; want 명사 and 감탄사", "안녕", entry.Groups)
+	}
+}
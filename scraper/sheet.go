@@ -0,0 +1,350 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// VocabularySheet is a printable handout of Entries, with a row of the
+// word, hanja, reading, meaning and first example of each.
+type VocabularySheet struct {
+	Title   string // Heading of every page. Vocabulary, in the locale, if empty.
+	Entries []Entry
+	Quiz    bool   // Leave the meanings blank, for students to fill in.
+	Locale  string // Of the column headings. DefaultLocale if empty.
+}
+
+// Number of rows on each page of an HTML vocabulary sheet.
+const sheetrows = 12
+
+// sheetrow is the text of each column of a row.
+type sheetrow struct {
+	word    string
+	hanja   string
+	reading string
+	meaning string
+	example Markup
+}
+
+func (sheet VocabularySheet) locale() string {
+	if sheet.Locale == "" {
+		return DefaultLocale
+	}
+	return sheet.Locale
+}
+
+func (sheet VocabularySheet) title() string {
+	if sheet.Title == "" {
+		return Translate(sheet.locale(), LabelVocabulary)
+	}
+	return sheet.Title
+}
+
+func (sheet VocabularySheet) headings() []string {
+	headings := []string{}
+	for _, key := range []string{LabelWord, LabelHanja, LabelReading, LabelMeaning, LabelExample} {
+		headings = append(headings, Translate(sheet.locale(), key))
+	}
+	return headings
+}
+
+func (sheet VocabularySheet) rows() []sheetrow {
+	rows := make([]sheetrow, len(sheet.Entries))
+	for i, entry := range sheet.Entries {
+		row := sheetrow{word: entry.Title, hanja: entry.Hanja, reading: entry.Romanisation}
+		if entry.Pronunciation != "" {
+			row.reading = strings.TrimSpace(row.reading + " [" + entry.Pronunciation + "]")
+		}
+		if !sheet.Quiz {
+			row.meaning = strings.Join(entry.Glosses, "; ")
+		}
+		for _, sense := range entry.Senses {
+			if len(sense.Examples) > 0 {
+				row.example = sense.Examples[0]
+				break
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// Style of HTML vocabulary sheets, for printing on A4 paper.
+const sheetstyle = `@page { size: A4; margin: 15mm; }
+body { font-family: "Noto Sans KR", "NanumBarunGothic", "Nanum Gothic", "Apple SD Gothic Neo", "Malgun Gothic", sans-serif; font-size: 10pt; color: #212121; margin: 0; }
+.page { break-after: page; }
+.page:last-child { break-after: auto; }
+header { display: flex; justify-content: space-between; align-items: baseline; margin-bottom: 8pt; }
+h1 { font-size: 16pt; margin: 0; }
+.name { min-width: 45%; border-bottom: 0.5pt solid #212121; }
+table { width: 100%; border-collapse: collapse; table-layout: fixed; }
+th, td { border: 0.5pt solid #bdbdbd; padding: 4pt; text-align: left; vertical-align: top; overflow-wrap: anywhere; }
+th { background: #eeeeee; font-size: 9pt; }
+tr { break-inside: avoid; }
+.word { font-size: 11pt; font-weight: bold; }
+.quiz .meaning { height: 2.6em; }
+mark { background: none; color: #c62828; font-weight: bold; }
+footer { margin-top: 6pt; text-align: center; font-size: 8pt; color: #757575; }
+@media screen { body { max-width: 180mm; margin: 12pt auto; } .page { margin-bottom: 24pt; } }`
+
+// Widths of the columns of vocabulary sheets, as fractions of the page.
+var sheetcolumns = []float64{0.15, 0.12, 0.17, 0.26, 0.30}
+
+// Write the sheet as an HTML document, with a page of at most 12 rows for
+// each printed page.
+func (sheet VocabularySheet) WriteHTML(writer io.Writer) error {
+	locale, title, rows := sheet.locale(), EscapeHTML(sheet.title()), sheet.rows()
+	pages := (len(rows) + sheetrows - 1) / sheetrows
+	if pages == 0 {
+		pages = 1
+	}
+	lines := []string{
+		"<!DOCTYPE html>",
+		fmt.Sprintf(`<html lang="%s">`, locale),
+		`<head>`,
+		`<meta charset="utf-8">`,
+		`<title>` + title + `</title>`,
+		`<style>`, sheetstyle, `</style>`,
+		`</head>`,
+	}
+	if sheet.Quiz {
+		lines = append(lines, `<body class="quiz">`)
+	} else {
+		lines = append(lines, `<body>`)
+	}
+	for page := 0; page < pages; page++ {
+		lines = append(lines, `<section class="page">`, `<header>`, `<h1>`+title+`</h1>`)
+		if sheet.Quiz {
+			lines = append(lines, `<span class="name">`+EscapeHTML(Translate(locale, LabelName))+`</span>`)
+		}
+		lines = append(lines, `</header>`, `<table>`, `<colgroup>`)
+		for _, width := range sheetcolumns {
+			lines = append(lines, fmt.Sprintf(`<col style="width: %.0f%%">`, width*100))
+		}
+		lines = append(lines, `</colgroup>`, `<thead>`, `<tr>`)
+		for _, heading := range sheet.headings() {
+			lines = append(lines, `<th>`+EscapeHTML(heading)+`</th>`)
+		}
+		lines = append(lines, `</tr>`, `</thead>`, `<tbody>`)
+		for _, row := range rows[page*sheetrows : min((page+1)*sheetrows, len(rows))] {
+			lines = append(lines, `<tr>`,
+				`<td class="word" lang="ko">`+EscapeHTML(row.word)+`</td>`,
+				`<td class="hanja" lang="ko">`+EscapeHTML(row.hanja)+`</td>`,
+				`<td class="reading">`+EscapeHTML(row.reading)+`</td>`,
+				`<td class="meaning">`+EscapeHTML(row.meaning)+`</td>`,
+				`<td class="example" lang="ko">`+row.example.Render(EscapeHTML, htmlwrap)+`</td>`,
+				`</tr>`)
+		}
+		lines = append(lines, `</tbody>`, `</table>`, fmt.Sprintf(`<footer>%d / %d</footer>`, page+1, pages), `</section>`)
+	}
+	lines = append(lines, `</body>`, `</html>`, "")
+	_, errwrite := io.WriteString(writer, strings.Join(lines, "\n"))
+	return errwrite
+}
+
+// Layout of PDF vocabulary sheets, in points, on A4 paper.
+const (
+	sheetwidth   = 595.28
+	sheetheight  = 841.89
+	sheetmargin  = 42.0
+	sheetpadding = 4.0
+	sheetleading = 1.35 // Distance between baselines, in font sizes.
+	sheettext    = 9.0  // Font size of cells.
+	sheetword    = 11.0 // Font size of words.
+)
+
+// sheetcell is the text of a cell, with emphasis, wrapped into lines.
+type sheetcell struct {
+	markup Markup
+	size   float64
+	lines  [][2]int // Byte offsets into the text of each line.
+}
+
+// Write the sheet as a PDF document, with subsets of pdffonts embedded.
+func (sheet VocabularySheet) WritePDF(writer io.Writer) error {
+	text := newpdftext(pdffonts()...)
+	locale, title := sheet.locale(), sheet.title()
+	textwidth := sheetwidth - 2*sheetmargin
+	widths := make([]float64, len(sheetcolumns))
+	for i, fraction := range sheetcolumns {
+		widths[i] = textwidth * fraction
+	}
+
+	// Lay out every page before drawing, to number the pages.
+	pages := []*strings.Builder{}
+	y := 0.0
+	newpage := func() {
+		page := &strings.Builder{}
+		pages = append(pages, page)
+		y = sheetheight - sheetmargin - 16
+		page.WriteString("0.13 g\n")
+		page.WriteString(text.show(title, 16, sheetmargin, y))
+		if sheet.Quiz {
+			name := Translate(locale, LabelName)
+			x := sheetmargin + textwidth*0.55
+			page.WriteString(text.show(name, sheettext, x, y))
+			fmt.Fprintf(page, "0.13 G 0.5 w %.2f %.2f m %.2f %.2f l S\n", x+text.measure(name, sheettext)+4, y-2, sheetwidth-sheetmargin, y-2)
+		}
+		y -= 14
+	}
+	drawrow := func(cells []sheetcell, background bool, minimum float64) {
+		height := minimum
+		for i := range cells {
+			cells[i].lines = wrapsheet(text, cells[i].markup.Text, widths[i]-2*sheetpadding, cells[i].size)
+			height = max(height, float64(len(cells[i].lines))*cells[i].size*sheetleading+2*sheetpadding)
+		}
+		if y-height < sheetmargin+16 {
+			newpage()
+		}
+		page := pages[len(pages)-1]
+		if background {
+			fmt.Fprintf(page, "0.93 g %.2f %.2f %.2f %.2f re f\n", sheetmargin, y-height, textwidth, height)
+		}
+		x := sheetmargin
+		for i, cell := range cells {
+			baseline := y - sheetpadding - cell.size
+			for _, line := range cell.lines {
+				page.WriteString(showsheetline(text, cell.markup, line, cell.size, x+sheetpadding, baseline))
+				baseline -= cell.size * sheetleading
+			}
+			fmt.Fprintf(page, "0.74 G 0.5 w %.2f %.2f %.2f %.2f re S\n", x, y-height, widths[i], height)
+			x += widths[i]
+		}
+		y -= height
+	}
+
+	headings := []sheetcell{}
+	for _, heading := range sheet.headings() {
+		headings = append(headings, sheetcell{markup: Markup{Text: heading}, size: sheettext})
+	}
+	newpage()
+	drawrow(headings, true, 0)
+	for _, row := range sheet.rows() {
+		count := len(pages)
+		cells := []sheetcell{
+			{markup: Markup{Text: row.word}, size: sheetword},
+			{markup: Markup{Text: row.hanja}, size: sheettext},
+			{markup: Markup{Text: row.reading}, size: sheettext},
+			{markup: Markup{Text: row.meaning}, size: sheettext},
+			{markup: row.example, size: sheettext},
+		}
+		minimum := 0.0
+		if sheet.Quiz {
+			minimum = 3*sheettext*sheetleading + 2*sheetpadding // Room to write the meaning.
+		}
+		drawrow(cells, false, minimum)
+		if len(pages) > count {
+			// The row moved to a new page: draw it again below the headings.
+			pages = pages[:count]
+			newpage()
+			drawrow(headings, true, 0)
+			drawrow(cells, false, minimum)
+		}
+	}
+
+	document := &pdfwriter{}
+	parent := document.reserve()
+	kids := []string{}
+	contents := []int{}
+	for i, page := range pages {
+		footer := fmt.Sprintf("%d / %d", i+1, len(pages))
+		page.WriteString("0.46 g\n")
+		page.WriteString(text.show(footer, 8, (sheetwidth-text.measure(footer, 8))/2, sheetmargin/2))
+		contents = append(contents, document.stream("", []byte(page.String())))
+	}
+	resources, errembed := text.embed(document)
+	if errembed != nil {
+		return errembed
+	}
+	for _, content := range contents {
+		kid := document.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font %s >> /Contents %d 0 R >>", parent, sheetwidth, sheetheight, resources, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", kid))
+	}
+	document.set(parent, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	catalog := document.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /Lang %s >>", parent, pdfstring(locale)))
+	info := document.add(fmt.Sprintf("<< /Title %s /Producer %s >>", pdfstring(title), pdfstring("NaverDict")))
+	return document.write(writer, catalog, info)
+}
+
+// Fonts embedded in PDF vocabulary sheets: NanumBarunGothic for Hangul, Go
+// Regular for Latin text, which NanumBarunGothic lacks, and Noto Sans CJK
+// KR for hanja. Parsed on first use, like the card fonts.
+var pdffonts = sync.OnceValue(func() []pdffontfile {
+	return []pdffontfile{
+		{"NanumBarunGothic", nanumbarungothic, mustparsefont(nanumbarungothic)},
+		{"GoRegular", goregular.TTF, mustparsefont(goregular.TTF)},
+		{"NotoSansCJKkrHanja-Bold", notosanskrhanja, mustparsefont(notosanskrhanja)},
+	}
+})
+
+// Wrap text into lines of at most width points, breaking between words,
+// or within words longer than a line.
+func wrapsheet(text *pdftext, line string, width float64, size float64) [][2]int {
+	lines := [][2]int{}
+	start, end, linewidth := 0, 0, 0.0
+	for offset := 0; offset < len(line); {
+		next := strings.IndexByte(line[offset:], ' ')
+		if next < 0 {
+			next = len(line)
+		} else {
+			next += offset + 1
+		}
+		word := line[offset:next]
+		wordwidth := text.measure(strings.TrimRight(word, " "), size)
+		switch {
+		case linewidth == 0 && wordwidth > width:
+			// Break a long word at the last character that fits.
+			for _, char := range word {
+				charwidth := text.measure(string(char), size)
+				if linewidth+charwidth > width && end > start {
+					lines = append(lines, [2]int{start, end})
+					start, linewidth = end, 0
+				}
+				end += utf8.RuneLen(char)
+				linewidth += charwidth
+			}
+			linewidth = text.measure(line[start:end], size)
+		case linewidth > 0 && linewidth+wordwidth > width:
+			lines = append(lines, [2]int{start, end})
+			start, end, linewidth = offset, next, text.measure(word, size)
+		default:
+			end = next
+			linewidth += text.measure(word, size)
+		}
+		offset = next
+	}
+	if end > start {
+		lines = append(lines, [2]int{start, end})
+	}
+	return lines
+}
+
+// Content operators that show a line of a Markup, with emphasis in red.
+func showsheetline(text *pdftext, markup Markup, line [2]int, size float64, x float64, y float64) string {
+	var operators strings.Builder
+	for offset := line[0]; offset < line[1]; {
+		end, emphasised := line[1], false
+		for _, span := range markup.Spans {
+			if span.Start <= offset && offset < span.End {
+				end, emphasised = min(end, span.End), true
+			} else if offset < span.Start && span.Start < end {
+				end = span.Start
+			}
+		}
+		piece := strings.TrimRight(markup.Text[offset:end], " ")
+		if emphasised {
+			operators.WriteString("0.78 0.16 0.16 rg\n")
+		} else {
+			operators.WriteString("0.13 g\n")
+		}
+		operators.WriteString(text.show(piece, size, x, y))
+		x += text.measure(markup.Text[offset:end], size)
+		offset = end
+	}
+	return operators.String()
+}
@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+func TestVocabularySheetHTML(t *testing.T) {
+	entries := make([]Entry, sheetrows+1)
+	for i := range entries {
+		entries[i] = renderentry
+	}
+	var output strings.Builder
+	errwrite := VocabularySheet{Entries: entries, Locale: Korean}.WriteHTML(&output)
+	got := output.String()
+	if errwrite != nil {
+		t.Fatalf("WriteHTML() error = %v", errwrite)
+	}
+	for _, want := range []string{
+		`<html lang="ko">`,
+		`<title>어휘</title>`,
+		`<th>단어</th>`,
+		`<td class="reading">an-nyeong [안녕]</td>`,
+		`<td class="meaning">hello; peace</td>`,
+		`<td class="example" lang="ko">가족의 <mark>안녕</mark>을 빌다.</td>`,
+		`<footer>2 / 2</footer>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteHTML() = %q; want it to contain %q", got, want)
+		}
+	}
+	if pages := strings.Count(got, `<section class="page">`); pages != 2 {
+		t.Errorf("WriteHTML() of %d entries has %d pages; want 2", len(entries), pages)
+	}
+
+	output.Reset()
+	VocabularySheet{Title: "Week <1>", Entries: entries[:1], Quiz: true}.WriteHTML(&output)
+	got = output.String()
+	for _, want := range []string{`<h1>Week &lt;1&gt;</h1>`, `<body class="quiz">`, `<span class="name">Name:</span>`, `<td class="meaning"></td>`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteHTML(quiz) = %q; want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestVocabularySheetPDF(t *testing.T) {
+	var output bytes.Buffer
+	entries := []Entry{renderentry, {Title: "나무", Glosses: []string{"tree"}}}
+	errwrite := VocabularySheet{Entries: entries}.WritePDF(&output)
+	if errwrite != nil {
+		t.Fatalf("WritePDF() error = %v", errwrite)
+	}
+	document := output.Bytes()
+	if !bytes.HasPrefix(document, []byte("%PDF-1.")) || !bytes.HasSuffix(document, []byte("%%EOF\n")) {
+		t.Fatalf("WritePDF() = %q...; want a PDF document", document[:min(len(document), 16)])
+	}
+
+	// Every object is where the cross-reference table says it is.
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(document)
+	if startxref == nil {
+		t.Fatalf("WritePDF() has no startxref")
+	}
+	offset, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(document[offset:], []byte("xref")) {
+		t.Fatalf("WritePDF() startxref = %d; want the offset of the xref table", offset)
+	}
+	fonts := [][]byte{}
+	for i, match := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(document[offset:], -1) {
+		start, _ := strconv.Atoi(string(match[1]))
+		object := document[start:]
+		if !bytes.HasPrefix(object, []byte(strconv.Itoa(i+1)+" 0 obj")) {
+			t.Fatalf("WritePDF() object %d is not at offset %d", i+1, start)
+		}
+		object = object[:bytes.Index(object, []byte("\nendobj"))]
+		if bytes.Contains(object, []byte("/Length1")) {
+			data := object[bytes.Index(object, []byte("stream\n"))+7 : bytes.LastIndex(object, []byte("\nendstream"))]
+			reader, errreader := zlib.NewReader(bytes.NewReader(data))
+			if errreader != nil {
+				t.Fatalf("WritePDF() font %d is not compressed: %v", i+1, errreader)
+			}
+			font, _ := io.ReadAll(reader)
+			fonts = append(fonts, font)
+		}
+	}
+
+	// Hangul, Latin text and hanja are in embedded subsets.
+	if len(fonts) != 3 {
+		t.Fatalf("WritePDF() embeds %d fonts; want 3", len(fonts))
+	}
+	for _, font := range fonts {
+		if _, errparse := sfnt.Parse(font); errparse != nil {
+			t.Errorf("WritePDF() embeds a font that does not parse: %v", errparse)
+		}
+	}
+	if len(fonts[0]) >= len(nanumbarungothic) {
+		t.Errorf("WritePDF() embeds %d bytes of NanumBarunGothic; want a subset of fewer than %d", len(fonts[0]), len(nanumbarungothic))
+	}
+	if len(fonts[2]) >= len(notosanskrhanja)/10 {
+		t.Errorf("WritePDF() embeds %d bytes of hanja; want a subset of fewer than %d", len(fonts[2]), len(notosanskrhanja)/10)
+	}
+	if bytes.Contains(document, []byte("HYGoThic-Medium")) {
+		t.Errorf("WritePDF() uses HYGoThic-Medium; want only embedded fonts")
+	}
+}
+
+func TestPDFText(t *testing.T) {
+	text := newpdftext(pdffonts()...)
+	got := text.show("가a漢", 10, 1, 2)
+	want := "BT 1.00 2.00 Td /F1 10.00 Tf <" // Glyph indexes follow.
+	if !strings.HasPrefix(got, want) || !strings.Contains(got, "/F2 10.00 Tf <") || !strings.Contains(got, "/F3 10.00 Tf <") {
+		t.Errorf("show(%q) = %q; want 가 in F1, a in F2 and 漢 in F3", "가a漢", got)
+	}
+	if width := text.measure("漢", 10); width != 10 {
+		t.Errorf("measure(%q, 10) = %v; want 10", "漢", width)
+	}
+	// Characters outside every font are shown as ? of Go Regular.
+	if got, want := text.show("𠀀", 10, 1, 2), text.show("?", 10, 1, 2); got != want {
+		t.Errorf("show(%q) = %q; want %q", "𠀀", got, want)
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	if got, want := pdfstring("어휘"), "<FEFFC5B4D718>"; got != want {
+		t.Errorf("pdfstring(%q) = %q; want %q", "어휘", got, want)
+	}
+}
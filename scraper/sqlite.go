@@ -0,0 +1,446 @@
+package scraper
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A minimal writer of SQLite 3 database files, see
+// https://www.sqlite.org/fileformat2.html. It writes a whole database at
+// once, as needed for Anki packages, with tables and indexes of integers
+// and text only.
+
+// Size of every page of the database.
+const sqlitepagesize = 4096
+
+// sqlitetable is a table and its rows. With a rowid alias, i.e. an INTEGER
+// PRIMARY KEY as the first column, the first value of each row is its
+// rowid; otherwise rows are numbered from 1.
+type sqlitetable struct {
+	name       string
+	sql        string
+	rowidalias bool
+	rows       [][]interface{} // Of int64 and string.
+	indexes    []sqliteindex
+}
+
+// sqliteindex is an index on columns of a table.
+type sqliteindex struct {
+	name    string
+	sql     string
+	columns []int
+}
+
+// sqlitewriter lays out the pages of a database, numbered from 1.
+type sqlitewriter struct {
+	pages [][]byte
+}
+
+func (writer *sqlitewriter) allocate() int {
+	writer.pages = append(writer.pages, make([]byte, sqlitepagesize))
+	return len(writer.pages)
+}
+
+func (writer *sqlitewriter) page(number int) []byte {
+	return writer.pages[number-1]
+}
+
+// Write a database with tables, in order.
+func writesqlite(tables []sqlitetable) ([]byte, error) {
+	writer := &sqlitewriter{}
+	writer.allocate() // Page 1 holds the header and the sqlite_master table.
+
+	master := [][]interface{}{}
+	for _, table := range tables {
+		entries := make([]sqliteentry, len(table.rows))
+		for i, row := range table.rows {
+			rowid := int64(i + 1)
+			values := row
+			if table.rowidalias {
+				rowid = row[0].(int64)
+				values = append([]interface{}{nil}, row[1:]...)
+			}
+			entries[i] = sqliteentry{rowid: rowid, values: values, row: row}
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].rowid < entries[j].rowid
+		})
+		for i := 1; i < len(entries); i++ {
+			if entries[i].rowid == entries[i-1].rowid {
+				msg := fmt.Sprintf("duplicate rowid %d in table %s", entries[i].rowid, table.name)
+				return nil, errors.New(msg)
+			}
+		}
+		root, errtable := writer.tabletree(entries)
+		if errtable != nil {
+			return nil, errtable
+		}
+		master = append(master, []interface{}{"table", table.name, table.name, int64(root), table.sql})
+
+		for _, index := range table.indexes {
+			keys := make([][]interface{}, len(entries))
+			for i, entry := range entries {
+				for _, column := range index.columns {
+					keys[i] = append(keys[i], entry.row[column])
+				}
+				keys[i] = append(keys[i], entry.rowid)
+			}
+			sort.SliceStable(keys, func(i, j int) bool {
+				return comparesqlite(keys[i], keys[j]) < 0
+			})
+			root, errindex := writer.indextree(keys)
+			if errindex != nil {
+				return nil, errindex
+			}
+			master = append(master, []interface{}{"index", index.name, table.name, int64(root), index.sql})
+		}
+	}
+
+	// sqlite_master must fit in page 1, after the database header.
+	cells := make([][]byte, len(master))
+	size := 100 + 8
+	for i, row := range master {
+		cell, errcell := writer.tablecell(int64(i+1), row)
+		if errcell != nil {
+			return nil, errcell
+		}
+		cells[i] = cell
+		size += len(cell) + 2
+	}
+	if size > sqlitepagesize {
+		return nil, errors.New("sqlite schema does not fit in the first page")
+	}
+	writer.writepage(1, 0x0D, cells, 0)
+	writer.writeheader()
+
+	database := make([]byte, 0, len(writer.pages)*sqlitepagesize)
+	for _, page := range writer.pages {
+		database = append(database, page...)
+	}
+	return database, nil
+}
+
+// sqliteentry is a row of a table being written.
+type sqliteentry struct {
+	rowid  int64
+	values []interface{} // As stored, i.e. without the rowid alias.
+	row    []interface{}
+}
+
+// Write the database header into page 1.
+func (writer *sqlitewriter) writeheader() {
+	header := writer.page(1)
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], sqlitepagesize)
+	header[18], header[19] = 1, 1 // Legacy journal mode.
+	header[21], header[22], header[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(header[24:], 1) // File change counter.
+	binary.BigEndian.PutUint32(header[28:], uint32(len(writer.pages)))
+	binary.BigEndian.PutUint32(header[40:], 1) // Schema cookie.
+	binary.BigEndian.PutUint32(header[44:], 4) // Schema format.
+	binary.BigEndian.PutUint32(header[56:], 1) // UTF-8.
+	binary.BigEndian.PutUint32(header[92:], 1) // Version valid for the file change counter.
+	binary.BigEndian.PutUint32(header[96:], 3045000)
+}
+
+// Write cells into a b-tree page of a type, with a right-most child for
+// interior pages.
+func (writer *sqlitewriter) writepage(number int, pagetype byte, cells [][]byte, rightmost int) {
+	page := writer.page(number)
+	offset := 0
+	if number == 1 {
+		offset = 100
+	}
+	headersize := 8
+	if pagetype == 0x02 || pagetype == 0x05 {
+		headersize = 12
+		binary.BigEndian.PutUint32(page[offset+8:], uint32(rightmost))
+	}
+	page[offset] = pagetype
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(len(cells)))
+	content := sqlitepagesize
+	for i, cell := range cells {
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[offset+headersize+2*i:], uint16(content))
+	}
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(content))
+}
+
+// Pack cells into pages of at most sqlitepagesize bytes with a header of
+// headersize bytes, at least minimum cells to a page. Returns the index of
+// the first cell of each page.
+func packcells(cells [][]byte, headersize int, minimum int) []int {
+	starts := []int{0}
+	size := headersize
+	for i, cell := range cells {
+		if size+len(cell)+2 > sqlitepagesize && i-starts[len(starts)-1] >= minimum {
+			starts = append(starts, i)
+			size = headersize
+		}
+		size += len(cell) + 2
+	}
+	// Move cells from the previous page to fill the last one, which is
+	// possible as only small cells come in more than one to a page.
+	if last := len(starts) - 1; last > 0 && len(cells)-starts[last] < minimum {
+		starts[last] = len(cells) - minimum
+	}
+	return starts
+}
+
+// Write the b-tree of a table. Returns its root page.
+func (writer *sqlitewriter) tabletree(entries []sqliteentry) (int, error) {
+	cells := make([][]byte, len(entries))
+	for i, entry := range entries {
+		cell, errcell := writer.tablecell(entry.rowid, entry.values)
+		if errcell != nil {
+			return 0, errcell
+		}
+		cells[i] = cell
+	}
+
+	if len(entries) == 0 {
+		page := writer.allocate()
+		writer.writepage(page, 0x0D, nil, 0)
+		return page, nil
+	}
+
+	// Leaves, and the largest rowid in each.
+	children, keys := []int{}, []int64{}
+	starts := append(packcells(cells, 8, 1), len(cells))
+	for i := 0; i+1 < len(starts); i++ {
+		page := writer.allocate()
+		writer.writepage(page, 0x0D, cells[starts[i]:starts[i+1]], 0)
+		children = append(children, page)
+		keys = append(keys, entries[starts[i+1]-1].rowid)
+	}
+
+	// Interior pages, each child but the right-most with a cell.
+	for len(children) > 1 {
+		cells := make([][]byte, len(children))
+		for i, child := range children {
+			cell := binary.BigEndian.AppendUint32(nil, uint32(child))
+			cells[i] = appendvarint(cell, keys[i])
+		}
+		parents, parentkeys := []int{}, []int64{}
+		starts := append(packcells(cells, 12, 2), len(cells))
+		for i := 0; i+1 < len(starts); i++ {
+			last := starts[i+1] - 1
+			page := writer.allocate()
+			writer.writepage(page, 0x05, cells[starts[i]:last], children[last])
+			parents = append(parents, page)
+			parentkeys = append(parentkeys, keys[last])
+		}
+		children, keys = parents, parentkeys
+	}
+	return children[0], nil
+}
+
+// Write the b-tree of an index of sorted keys. Returns its root page.
+func (writer *sqlitewriter) indextree(keys [][]interface{}) (int, error) {
+	cells := make([][]byte, len(keys))
+	for i, key := range keys {
+		payload := sqliterecord(key)
+		if len(payload) > (sqlitepagesize-12)*64/255-23 {
+			return 0, errors.New("sqlite index key too large")
+		}
+		cells[i] = appendvarint(nil, int64(len(payload)))
+		cells[i] = append(cells[i], payload...)
+	}
+	if len(cells) == 0 {
+		page := writer.allocate()
+		writer.writepage(page, 0x0A, nil, 0)
+		return page, nil
+	}
+
+	// Leaves, with a divider key taken out between each pair.
+	children, dividers := []int{}, [][]byte{}
+	for len(cells) > 0 {
+		starts := packcells(cells, 8, 2)
+		end := len(cells)
+		if len(starts) > 1 {
+			end = starts[1] - 1 // Keep the divider out of the leaf.
+		}
+		page := writer.allocate()
+		writer.writepage(page, 0x0A, cells[:end], 0)
+		children = append(children, page)
+		if end < len(cells) {
+			dividers = append(dividers, cells[end])
+			cells = cells[end+1:]
+		} else {
+			cells = nil
+		}
+	}
+
+	// Interior pages, with the dividers between their children as cells.
+	// Room for two more cells is kept, so that no page is left with only
+	// a right-most child.
+	reserve := 0
+	for _, divider := range dividers {
+		reserve = max(reserve, 2*(4+len(divider)+2))
+	}
+	for len(children) > 1 {
+		parents, parentdividers := []int{}, [][]byte{}
+		for len(children) > 0 {
+			cells := [][]byte{}
+			size := 12
+			count := 0
+			for count+1 < len(children) {
+				cell := binary.BigEndian.AppendUint32(nil, uint32(children[count]))
+				cell = append(cell, dividers[count]...)
+				if size+len(cell)+2 > sqlitepagesize-reserve && count >= 2 && count+2 < len(children) {
+					break
+				}
+				cells = append(cells, cell)
+				size += len(cell) + 2
+				count++
+			}
+			page := writer.allocate()
+			writer.writepage(page, 0x02, cells, children[count])
+			parents = append(parents, page)
+			if count+1 < len(children) {
+				parentdividers = append(parentdividers, dividers[count])
+				children, dividers = children[count+1:], dividers[count+1:]
+			} else {
+				children, dividers = nil, nil
+			}
+		}
+		children, dividers = parents, parentdividers
+	}
+	return children[0], nil
+}
+
+// Build a table leaf cell, spilling a large payload into overflow pages.
+func (writer *sqlitewriter) tablecell(rowid int64, values []interface{}) ([]byte, error) {
+	payload := sqliterecord(values)
+	cell := appendvarint(nil, int64(len(payload)))
+	cell = appendvarint(cell, rowid)
+
+	usable := sqlitepagesize
+	maxlocal := usable - 35
+	if len(payload) <= maxlocal {
+		return append(cell, payload...), nil
+	}
+	minlocal := (usable-12)*32/255 - 23
+	local := minlocal + (len(payload)-minlocal)%(usable-4)
+	if local > maxlocal {
+		local = minlocal
+	}
+	cell = append(cell, payload[:local]...)
+	rest := payload[local:]
+	first := writer.allocate()
+	cell = binary.BigEndian.AppendUint32(cell, uint32(first))
+	for page := first; len(rest) > 0; {
+		chunk := rest
+		if len(chunk) > usable-4 {
+			chunk = chunk[:usable-4]
+		}
+		rest = rest[len(chunk):]
+		next := 0
+		if len(rest) > 0 {
+			next = writer.allocate()
+		}
+		binary.BigEndian.PutUint32(writer.page(page), uint32(next))
+		copy(writer.page(page)[4:], chunk)
+		page = next
+	}
+	return cell, nil
+}
+
+// Encode values as a record.
+func sqliterecord(values []interface{}) []byte {
+	types, body := []byte{}, []byte{}
+	for _, value := range values {
+		switch value := value.(type) {
+		case nil:
+			types = appendvarint(types, 0)
+		case int64:
+			serialtype, size := int64(6), 8
+			for _, candidate := range []struct {
+				serialtype int64
+				size       int
+			}{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 6}} {
+				limit := int64(1) << (8*candidate.size - 1)
+				if value >= -limit && value < limit {
+					serialtype, size = candidate.serialtype, candidate.size
+					break
+				}
+			}
+			types = appendvarint(types, serialtype)
+			for i := size - 1; i >= 0; i-- {
+				body = append(body, byte(value>>(8*i)))
+			}
+		case string:
+			types = appendvarint(types, int64(2*len(value)+13))
+			body = append(body, value...)
+		}
+	}
+	// The header size counts itself, so it may take an extra byte.
+	headersize := int64(len(types) + 1)
+	if len(appendvarint(nil, headersize)) > 1 {
+		headersize++
+	}
+	record := appendvarint(nil, headersize)
+	record = append(record, types...)
+	return append(record, body...)
+}
+
+// Append a SQLite varint, which is big-endian with up to 9 bytes.
+func appendvarint(buffer []byte, value int64) []byte {
+	unsigned := uint64(value)
+	if unsigned > 0x00FFFFFFFFFFFFFF {
+		buffer = append(buffer, make([]byte, 9)...)
+		tail := buffer[len(buffer)-9:]
+		tail[8] = byte(unsigned)
+		unsigned >>= 8
+		for i := 7; i >= 0; i-- {
+			tail[i] = byte(unsigned&0x7F) | 0x80
+			unsigned >>= 7
+		}
+		return buffer
+	}
+	groups := []byte{byte(unsigned & 0x7F)}
+	for unsigned >>= 7; unsigned > 0; unsigned >>= 7 {
+		groups = append(groups, byte(unsigned&0x7F)|0x80)
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		buffer = append(buffer, groups[i])
+	}
+	return buffer
+}
+
+// Compare keys in SQLite's order, where NULL < integers < text.
+func comparesqlite(a []interface{}, b []interface{}) int {
+	rank := func(value interface{}) int {
+		switch value.(type) {
+		case nil:
+			return 0
+		case int64:
+			return 1
+		}
+		return 2
+	}
+	for i := range a {
+		if rank(a[i]) != rank(b[i]) {
+			return rank(a[i]) - rank(b[i])
+		}
+		switch x := a[i].(type) {
+		case int64:
+			y := b[i].(int64)
+			if x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		case string:
+			if comparison := strings.Compare(x, b[i].(string)); comparison != 0 {
+				return comparison
+			}
+		}
+	}
+	return 0
+}
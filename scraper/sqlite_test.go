@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestAppendVarint(t *testing.T) {
+	tests := []struct {
+		value int64
+		want  []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7F}},
+		{128, []byte{0x81, 0x00}},
+		{16384, []byte{0x81, 0x80, 0x00}},
+		{-1, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+	for _, test := range tests {
+		got := appendvarint(nil, test.value)
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("appendvarint(%d) = %x; want %x", test.value, got, test.want)
+		}
+	}
+}
+
+func TestSQLiteRecord(t *testing.T) {
+	got := sqliterecord([]interface{}{nil, int64(1), int64(-300), "ab"})
+	want := []byte{0x05, 0x00, 0x01, 0x02, 0x11, 0x01, 0xFE, 0xD4, 'a', 'b'}
+	if !bytes.Equal(got, want) {
+		t.Errorf("sqliterecord() = %x; want %x", got, want)
+	}
+}
+
+func TestWriteSQLite(t *testing.T) {
+	table := sqlitetable{
+		name:       "words",
+		sql:        "CREATE TABLE words (id integer primary key, word text not null, count integer not null)",
+		rowidalias: true,
+		indexes:    []sqliteindex{{"ix_words_count", "CREATE INDEX ix_words_count on words (count)", []int{2}}},
+	}
+	for i := int64(1); i <= 2000; i++ {
+		word := string(bytes.Repeat([]byte("가"), int(i%3000))) // Some rows overflow.
+		table.rows = append(table.rows, []interface{}{i, word, i % 7})
+	}
+	database, errwrite := writesqlite([]sqlitetable{table})
+	if errwrite != nil {
+		t.Fatalf("writesqlite() = %v; want no error", errwrite)
+	}
+	if !bytes.HasPrefix(database, []byte("SQLite format 3\x00")) {
+		t.Errorf("writesqlite() has header %q; want SQLite format 3", database[:16])
+	}
+	pages := binary.BigEndian.Uint32(database[28:])
+	if int(pages)*sqlitepagesize != len(database) {
+		t.Errorf("writesqlite() has %d pages in header and %d bytes; want matching", pages, len(database))
+	}
+	// sqlite_master lists the table and the index.
+	if database[100] != 0x0D || binary.BigEndian.Uint16(database[103:]) != 2 {
+		t.Errorf("writesqlite() has sqlite_master page type %x with %d cells; want 0d with 2", database[100], binary.BigEndian.Uint16(database[103:]))
+	}
+
+	_, errduplicate := writesqlite([]sqlitetable{{name: "t", sql: "CREATE TABLE t (id integer primary key)", rowidalias: true,
+		rows: [][]interface{}{{int64(1)}, {int64(1)}}}})
+	if errduplicate == nil {
+		t.Errorf("writesqlite(duplicate rowids) = nil; want error")
+	}
+}
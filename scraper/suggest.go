@@ -0,0 +1,329 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum number of suggestions returned for a query.
+const SuggestLimit = 10
+
+// How long upstream suggestions are kept before being fetched again.
+const SuggestCacheTTL = 10 * time.Minute
+
+// Most queries kept in the suggestion cache. Expired queries are dropped
+// first, then those closest to expiring.
+const SuggestCacheLimit = 1024
+
+type suggestcacheitem struct {
+	suggestions []string
+	expiry      time.Time
+}
+
+// suggestcache stores upstream suggestions keyed by query.
+var suggestcache = struct {
+	sync.Mutex
+	items    map[string]suggestcacheitem
+	inflight map[string]*suggestcall
+}{
+	items:    map[string]suggestcacheitem{},
+	inflight: map[string]*suggestcall{},
+}
+
+// suggestcall lets concurrent requests for the same query share one upstream fetch.
+type suggestcall struct {
+	done        chan struct{}
+	suggestions []string
+	err         error
+}
+
+// Most headwords kept for suggestions when upstream is unavailable. Once
+// full, the headwords added first are dropped.
+const HeadwordLimit = 100000
+
+// localheadword is a headword with its decomposed jamo, see Decompose.
+type localheadword struct {
+	decomposed string
+	word       string
+}
+
+// headwords stores the headwords seen so far, sorted by their jamo so that
+// those starting with a query are found by binary search, and in the order
+// they were added to drop the oldest.
+var headwords = struct {
+	sync.RWMutex
+	sorted []localheadword
+	order  []localheadword
+}{}
+
+// Remove Illegal Characters from a partially typed search term.
+// Unlike Sanitise, standalone jamo (e.g. 사ㄹ) are kept.
+func SanitisePartial(unsanitised string) string {
+	sanitisationpattern := "[^a-zA-Z가-힣ㄱ-ㅎㅏ-ㅣ]"
+	re := regexp.MustCompile(sanitisationpattern)
+	sanitised := re.ReplaceAllString(unsanitised, "")
+	return sanitised
+}
+
+// Format Partial Search Term into Naver Dictionary Autocomplete Url.
+func GetSuggestUrl(query string) (string, error) {
+	hostname := "https://ac-dict.naver.com"
+	// Note: Korean Search Term MUST be utf-8 encoded!
+	Url, err := url.Parse(hostname)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse URL: %v", err)
+		return "", errors.New(msg)
+	}
+
+	Url.Path += "/koen/ac"
+	parameters := url.Values{}
+	parameters.Add("q", query)
+	parameters.Add("st", "11")
+	parameters.Add("r_lt", "11")
+	Url.RawQuery = parameters.Encode()
+
+	suggesturl := Url.String()
+	return suggesturl, nil
+}
+
+// Get the Suggested Headwords from the Autocomplete Information.
+func GetSuggestionList(suggestinfo map[string]interface{}) ([]string, error) {
+	// Equivalent to suggestInfo.items[0].map((item) => item[0][0]);
+	items, erroritems := suggestinfo["items"].([]interface{})
+	if !erroritems {
+		return nil, errors.New("cannot find items in suggestinfo")
+	}
+	if len(items) == 0 {
+		return []string{}, nil
+	}
+	group, errorgroup := items[0].([]interface{})
+	if !errorgroup {
+		return nil, errors.New("cannot find item group in items")
+	}
+
+	suggestions := make([]string, 0, len(group))
+	for _, item := range group {
+		fields, errorfields := item.([]interface{})
+		if !errorfields || len(fields) == 0 {
+			continue
+		}
+		field, errorfield := fields[0].([]interface{})
+		if !errorfield || len(field) == 0 {
+			continue
+		}
+		word, errorword := field[0].(string)
+		if !errorword || word == "" {
+			continue
+		}
+		suggestions = append(suggestions, word)
+	}
+	return suggestions, nil
+}
+
+// Get Autocomplete Suggestions from Naver Dictionary.
+func GetSuggestInfo(query string) ([]string, error) {
+	suggesturl, errsuggesturl := GetSuggestUrl(query)
+	if errsuggesturl != nil {
+		return nil, errsuggesturl
+	}
+	suggestinfo, errsuggestinfo := Fetch(suggesturl)
+	if errsuggestinfo != nil {
+		return nil, errsuggestinfo
+	}
+	suggestions, errsuggestions := GetSuggestionList(suggestinfo)
+	if errsuggestions != nil {
+		return nil, errsuggestions
+	}
+	return suggestions, nil
+}
+
+// Fetch suggestions for a query, reusing cached and in-flight results.
+func fetchsuggestions(query string) ([]string, error) {
+	suggestcache.Lock()
+	item, found := suggestcache.items[query]
+	if found && time.Now().Before(item.expiry) {
+		suggestcache.Unlock()
+		return item.suggestions, nil
+	}
+	if found {
+		delete(suggestcache.items, query)
+	}
+	call, inflight := suggestcache.inflight[query]
+	if inflight {
+		suggestcache.Unlock()
+		<-call.done
+		return call.suggestions, call.err
+	}
+	call = &suggestcall{done: make(chan struct{})}
+	suggestcache.inflight[query] = call
+	suggestcache.Unlock()
+
+	call.suggestions, call.err = GetSuggestInfo(query)
+
+	suggestcache.Lock()
+	delete(suggestcache.inflight, query)
+	if call.err == nil {
+		cachesuggestions(query, call.suggestions, time.Now())
+	}
+	suggestcache.Unlock()
+	close(call.done)
+
+	if call.err == nil {
+		AddHeadwords(call.suggestions)
+	}
+	return call.suggestions, call.err
+}
+
+// Cache suggestions for a query, evicting queries when the cache is full.
+// The caller holds the suggestcache lock.
+func cachesuggestions(query string, suggestions []string, now time.Time) {
+	if _, found := suggestcache.items[query]; !found && len(suggestcache.items) >= SuggestCacheLimit {
+		for cached, item := range suggestcache.items {
+			if !now.Before(item.expiry) {
+				delete(suggestcache.items, cached)
+			}
+		}
+		for len(suggestcache.items) >= SuggestCacheLimit {
+			oldest := ""
+			for cached, item := range suggestcache.items {
+				if oldest == "" || item.expiry.Before(suggestcache.items[oldest].expiry) {
+					oldest = cached
+				}
+			}
+			delete(suggestcache.items, oldest)
+		}
+	}
+	suggestcache.items[query] = suggestcacheitem{suggestions, now.Add(SuggestCacheTTL)}
+}
+
+// Remember headwords so they can be suggested when upstream is unavailable.
+func AddHeadwords(words []string) {
+	headwords.Lock()
+	defer headwords.Unlock()
+	added := map[string]bool{}
+	for _, word := range words {
+		if word == "" || added[word] {
+			continue
+		}
+		headword := localheadword{Decompose(word), word}
+		if _, found := findheadword(headword); found {
+			continue
+		}
+		added[word] = true
+		headwords.order = append(headwords.order, headword)
+	}
+	if len(added) == 0 {
+		return
+	}
+	// Sorting once is faster than inserting each headword of an offline index.
+	headwords.sorted = append(headwords.sorted, headwords.order[len(headwords.order)-len(added):]...)
+	sort.Slice(headwords.sorted, func(i, j int) bool { return lessheadword(headwords.sorted[i], headwords.sorted[j]) })
+	if excess := len(headwords.order) - HeadwordLimit; excess > 0 {
+		dropped := map[localheadword]bool{}
+		for _, headword := range headwords.order[:excess] {
+			dropped[headword] = true
+		}
+		kept := headwords.sorted[:0]
+		for _, headword := range headwords.sorted {
+			if !dropped[headword] {
+				kept = append(kept, headword)
+			}
+		}
+		headwords.sorted = kept
+		headwords.order = headwords.order[excess:]
+	}
+}
+
+// Position of a headword in the sorted headwords, or where it would be
+// inserted. The caller holds the headwords lock.
+func findheadword(headword localheadword) (int, bool) {
+	index := sort.Search(len(headwords.sorted), func(i int) bool { return !lessheadword(headwords.sorted[i], headword) })
+	return index, index < len(headwords.sorted) && headwords.sorted[index] == headword
+}
+
+// Order of the sorted headwords: by jamo, then by headword.
+func lessheadword(a localheadword, b localheadword) bool {
+	if a.decomposed != b.decomposed {
+		return a.decomposed < b.decomposed
+	}
+	return a.word < b.word
+}
+
+// Suggest previously seen headwords starting with the query.
+func GetLocalSuggestions(query string) []string {
+	prefix := Decompose(query)
+	headwords.RLock()
+	suggestions := []string{}
+	start := sort.Search(len(headwords.sorted), func(i int) bool { return headwords.sorted[i].decomposed >= prefix })
+	for _, headword := range headwords.sorted[start:] {
+		if !strings.HasPrefix(headword.decomposed, prefix) {
+			break
+		}
+		suggestions = append(suggestions, headword.word)
+	}
+	headwords.RUnlock()
+
+	// Shorter headwords first, as they are closer to what has been typed.
+	sort.Slice(suggestions, func(i, j int) bool {
+		if len(suggestions[i]) != len(suggestions[j]) {
+			return len(suggestions[i]) < len(suggestions[j])
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	if len(suggestions) > SuggestLimit {
+		suggestions = suggestions[:SuggestLimit]
+	}
+	return suggestions
+}
+
+// Hangul compatibility jamo indexed by their position in a composed syllable.
+var initialjamo = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+var medialjamo = []rune("ㅏㅐㅑㅒㅓㅔㅕㅖㅗㅘㅙㅚㅛㅜㅝㅞㅟㅠㅡㅢㅣ")
+var finaljamo = []rune(" ㄱㄲㄳㄴㄵㄶㄷㄹㄺㄻㄼㄽㄾㄿㅀㅁㅂㅄㅅㅆㅇㅈㅊㅋㅌㅍㅎ")
+
+// Decompose Hangul syllables into jamo, so that a partially typed
+// syllable (e.g. 살 while typing 사랑) still matches as a prefix.
+func Decompose(word string) string {
+	var builder strings.Builder
+	for _, r := range word {
+		if r < '가' || r > '힣' {
+			builder.WriteRune(r)
+			continue
+		}
+		index := int(r - '가')
+		builder.WriteRune(initialjamo[index/(21*28)])
+		builder.WriteRune(medialjamo[(index%(21*28))/28])
+		if final := index % 28; final != 0 {
+			builder.WriteRune(finaljamo[final])
+		}
+	}
+	return builder.String()
+}
+
+// Suggest Headwords for a Partial Search Term. (Public API)
+// Falls back to previously seen headwords when upstream is unavailable, and
+// reports whether it did, as those suggestions should not be cached long.
+func GetSuggestions(query string) ([]string, bool, error) {
+	sanitised := SanitisePartial(query)
+	if sanitised == "" {
+		return []string{}, false, nil
+	}
+	suggestions, errsuggestions := fetchsuggestions(sanitised)
+	if errsuggestions != nil {
+		local := GetLocalSuggestions(sanitised)
+		if len(local) == 0 {
+			return nil, false, errsuggestions
+		}
+		return local, true, nil
+	}
+	if len(suggestions) > SuggestLimit {
+		suggestions = suggestions[:SuggestLimit]
+	}
+	return suggestions, false, nil
+}
@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSanitisePartialKeepsJamo(t *testing.T) {
+	unsanitised := "사ㄹ!@#1"
+	want := "사ㄹ"
+	got := SanitisePartial(unsanitised)
+	if got != want {
+		t.Errorf("SanitisePartial(%q) = %q; want %q", unsanitised, got, want)
+	}
+}
+
+func TestGetSuggestUrl(t *testing.T) {
+	query := "사랑"
+	want := "https://ac-dict.naver.com/koen/ac?q=%EC%82%AC%EB%9E%91&r_lt=11&st=11"
+	got, error := GetSuggestUrl(query)
+	if error != nil {
+		t.Errorf("GetSuggestUrl(%q) = %q; want no error", query, error)
+	}
+	if got != want {
+		t.Errorf("GetSuggestUrl(%q) = %q; want %q", query, got, want)
+	}
+}
+
+func TestGetSuggestionList(t *testing.T) {
+	suggestinfo := map[string]interface{}{
+		"query": []interface{}{"사랑"},
+		"items": []interface{}{
+			[]interface{}{
+				[]interface{}{[]interface{}{"사랑"}, []interface{}{"love"}},
+				[]interface{}{[]interface{}{"사랑니"}, []interface{}{"wisdom tooth"}},
+			},
+		},
+	}
+	want := []string{"사랑", "사랑니"}
+	got, error := GetSuggestionList(suggestinfo)
+	if error != nil {
+		t.Errorf("GetSuggestionList(%v) = %q; want no error", suggestinfo, error)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSuggestionList(%v) = %q; want %q", suggestinfo, got, want)
+	}
+}
+
+func TestGetSuggestionListInvalid(t *testing.T) {
+	suggestinfo := map[string]interface{}{}
+	_, error := GetSuggestionList(suggestinfo)
+	if error == nil {
+		t.Errorf("GetSuggestionList(%v) = nil; want error", suggestinfo)
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	word := "살a"
+	want := "ㅅㅏㄹa"
+	got := Decompose(word)
+	if got != want {
+		t.Errorf("Decompose(%q) = %q; want %q", word, got, want)
+	}
+}
+
+func TestGetLocalSuggestions(t *testing.T) {
+	AddHeadwords([]string{"사랑니", "사랑", "사과"})
+	// 살 is typed on the way to 사랑.
+	for _, query := range []string{"사ㄹ", "살", "사라"} {
+		want := []string{"사랑", "사랑니"}
+		got := GetLocalSuggestions(query)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetLocalSuggestions(%q) = %q; want %q", query, got, want)
+		}
+	}
+}
+
+func TestHeadwordLimit(t *testing.T) {
+	headwords.Lock()
+	saved, savedorder := headwords.sorted, headwords.order
+	headwords.sorted, headwords.order = nil, nil
+	headwords.Unlock()
+	defer func() {
+		headwords.Lock()
+		headwords.sorted, headwords.order = saved, savedorder
+		headwords.Unlock()
+	}()
+
+	words := []string{"사랑"}
+	for i := 0; i < HeadwordLimit; i++ {
+		words = append(words, fmt.Sprintf("word%d", i))
+	}
+	AddHeadwords(words)
+	AddHeadwords([]string{"word1", "사과"})
+	if len(headwords.sorted) != HeadwordLimit || len(headwords.order) != HeadwordLimit {
+		t.Errorf("AddHeadwords() keeps %d headwords; want %d", len(headwords.sorted), HeadwordLimit)
+	}
+	if got := GetLocalSuggestions("사"); !reflect.DeepEqual(got, []string{"사과"}) {
+		t.Errorf("GetLocalSuggestions(%q) = %q; want the oldest headword dropped", "사", got)
+	}
+}
+
+func TestGetSuggestionsEmpty(t *testing.T) {
+	got, local, error := GetSuggestions("!@#")
+	if error != nil || local {
+		t.Errorf("GetSuggestions(%q) = %q; want no error", "!@#", error)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetSuggestions(%q) = %q; want empty", "!@#", got)
+	}
+}
+
+func TestSuggestCacheLimit(t *testing.T) {
+	suggestcache.Lock()
+	defer suggestcache.Unlock()
+	saved := suggestcache.items
+	defer func() { suggestcache.items = saved }()
+	suggestcache.items = map[string]suggestcacheitem{}
+
+	start := time.Now()
+	for i := 0; i < SuggestCacheLimit+10; i++ {
+		cachesuggestions(fmt.Sprint(i), nil, start.Add(time.Duration(i)*time.Millisecond))
+	}
+	if len(suggestcache.items) != SuggestCacheLimit {
+		t.Errorf("len(suggestcache.items) = %d; want %d", len(suggestcache.items), SuggestCacheLimit)
+	}
+	if _, found := suggestcache.items["0"]; found {
+		t.Errorf("suggestcache kept the oldest query when full")
+	}
+
+	// Expired queries are dropped at once, not one at a time.
+	cachesuggestions("later", nil, start.Add(SuggestCacheTTL+time.Hour))
+	if len(suggestcache.items) != 1 {
+		t.Errorf("len(suggestcache.items) after expiry = %d; want 1", len(suggestcache.items))
+	}
+}
@@ -0,0 +1,163 @@
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// TemplateRenderer renders an Entry with a text/template. The template is
+// executed on the Entry, so e.g. {{.Title}}, {{.Glosses}}, {{.Senses}} and
+// {{.TopikLevel.Label}} are available, as well as {{.DictInfo}} for the
+// Buildmessage fields. The functions in TemplateFuncs can also be used.
+type TemplateRenderer struct {
+	TemplateName string
+	Template     *template.Template
+	Format       *Format // Escapes and styles text in the escape, bold, italic, underline and markup functions. Nil is plain text.
+}
+
+// Plain text Format used by templates without one.
+var plainformat = &Format{
+	FormatName: "plain",
+	Escape:     func(text string) string { return text },
+	Wrap:       func(style string) (string, string) { return "", "" },
+}
+
+// Entry templates are checked against on creation, with every field set.
+var sampleentry = Entry{
+	Title:         "안녕",
+	Hanja:         "安寧",
+	Romanisation:  "an-nyeong",
+	Pronunciation: "안녕",
+	TopikLevel:    TopikElementary,
+	Importance:    3,
+	Glosses:       []string{"hello", "goodbye", "peace"},
+	Senses: []Sense{{
+		Number:              1,
+		PartOfSpeech:        "명사",
+		PartOfSpeechEnglish: "noun",
+		Gloss:               "peace; well-being",
+		Definition:          "The state of being at peace and without trouble.",
+		KoreanDefinition:    "아무 탈 없이 편안함.",
+		Examples:            []Markup{{Text: "가족의 안녕을 빌다.", Spans: []Span{{10, 16, StyleHighlight}}}},
+	}},
+	Groups: []SenseGroup{{"명사", "noun", []int{1}}},
+}
+
+// Functions available in templates, besides those of text/template.
+//
+//	stars 3                   ★★★
+//	numbered " " .Glosses     1.hello 2.goodbye 3.peace
+//	truncate 10 .Definition   At most 10 characters, ending with … when cut.
+//	join ", " .Glosses        hello, goodbye, peace
+//	escape .Title             Escapes text for the Format.
+//	bold/italic/underline .Title
+//	markup (index .Examples 0)  An example with its emphasis.
+func templatefuncs(format *Format) template.FuncMap {
+	return template.FuncMap{
+		"stars": func(count int) string {
+			if count < 0 {
+				count = 0
+			}
+			return strings.Repeat("★", count)
+		},
+		"numbered": func(separator string, items []string) string {
+			numbered := make([]string, len(items))
+			for i, item := range items {
+				numbered[i] = fmt.Sprintf("%d.%s", i+1, item)
+			}
+			return strings.Join(numbered, separator)
+		},
+		"truncate": Truncate,
+		"join": func(separator string, items []string) string {
+			return strings.Join(items, separator)
+		},
+		"escape":    format.Escape,
+		"bold":      func(text string) string { return format.styled(StyleBold, text) },
+		"italic":    func(text string) string { return format.styled(StyleItalic, text) },
+		"underline": func(text string) string { return format.styled(StyleUnderline, text) },
+		"markup": func(markup Markup) string {
+			return markup.Render(format.Escape, format.Wrap)
+		},
+	}
+}
+
+// Shorten text to at most limit characters, ending with … when it is cut.
+func Truncate(limit int, text string) string {
+	if limit <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// Create a TemplateRenderer. The template is executed on a sample Entry,
+// so that unknown fields and functions are reported now rather than on
+// the first lookup.
+func NewTemplateRenderer(name string, text string, format *Format) (*TemplateRenderer, error) {
+	if format == nil {
+		format = plainformat
+	}
+	parsed, errparse := template.New(name).Funcs(templatefuncs(format)).Parse(text)
+	if errparse != nil {
+		msg := fmt.Sprintf("invalid template %q: %v", name, errparse)
+		return nil, errors.New(msg)
+	}
+	renderer := &TemplateRenderer{name, parsed, format}
+	var output bytes.Buffer
+	errexecute := parsed.Execute(&output, sampleentry)
+	if errexecute != nil {
+		msg := fmt.Sprintf("invalid template %q: %v", name, errexecute)
+		return nil, errors.New(msg)
+	}
+	return renderer, nil
+}
+
+// Load a TemplateRenderer from a file, named after the file without its
+// extension unless a name is given.
+func LoadTemplateRenderer(name string, path string, format *Format) (*TemplateRenderer, error) {
+	data, errread := os.ReadFile(path)
+	if errread != nil {
+		msg := fmt.Sprintf("cannot read template: %v", errread)
+		return nil, errors.New(msg)
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return NewTemplateRenderer(name, string(data), format)
+}
+
+func (renderer *TemplateRenderer) Name() string {
+	return renderer.TemplateName
+}
+
+// Render an Entry, or report why the template failed on it.
+func (renderer *TemplateRenderer) Execute(entry Entry) (string, error) {
+	var output bytes.Buffer
+	errexecute := renderer.Template.Execute(&output, entry)
+	if errexecute != nil {
+		msg := fmt.Sprintf("cannot render template %q: %v", renderer.TemplateName, errexecute)
+		return "", errors.New(msg)
+	}
+	return strings.TrimRight(output.String(), "\n"), nil
+}
+
+// Render an Entry. Templates were checked on creation, but can still fail
+// on entries unlike the sample; those are logged and rendered as PlainText.
+func (renderer *TemplateRenderer) Render(entry Entry) string {
+	message, errexecute := renderer.Execute(entry)
+	if errexecute != nil {
+		log.Printf("%v, rendering %q as plain text", errexecute, entry.Title)
+		return PlainText.Render(entry)
+	}
+	return message
+}
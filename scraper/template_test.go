@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	text := "{{bold .Title}} {{stars .Importance}} {{numbered \", \" .Glosses}}" +
+		"{{range .Senses}}\n{{.Number}}. {{truncate 12 .Definition}}{{with .Examples}} {{markup (index . 0)}}{{end}}{{end}}"
+	renderer, error := NewTemplateRenderer("card", text, MarkdownV2)
+	if error != nil {
+		t.Fatalf("NewTemplateRenderer() = %q; want no error", error)
+	}
+	got := renderer.Render(renderentry)
+	want := "*안녕* ★★★ 1.hello, 2.peace\n1. The state o… 가족의 __안녕__을 빌다\\.\n2. "
+	if got != want {
+		t.Errorf("TemplateRenderer.Render() = %q; want %q", got, want)
+	}
+}
+
+func TestTemplateRendererInvalid(t *testing.T) {
+	tests := []string{
+		"{{.Title",         // Parse error.
+		"{{.Headword}}",    // Unknown field.
+		"{{shout .Title}}", // Unknown function.
+	}
+	for _, text := range tests {
+		_, error := NewTemplateRenderer("invalid", text, nil)
+		if error == nil {
+			t.Errorf("NewTemplateRenderer(%q) = nil; want error", text)
+		}
+	}
+}
+
+func TestTemplateRendererExecuteError(t *testing.T) {
+	// The sample entry has a third gloss, renderentry does not.
+	renderer, error := NewTemplateRenderer("third", "{{index .Glosses 2}}", nil)
+	if error != nil {
+		t.Fatalf("NewTemplateRenderer() = %q; want no error", error)
+	}
+	if _, error := renderer.Execute(renderentry); error == nil {
+		t.Errorf("TemplateRenderer.Execute() = nil; want error")
+	}
+	if got, want := renderer.Render(renderentry), PlainText.Render(renderentry); got != want {
+		t.Errorf("TemplateRenderer.Render() = %q; want plain text %q", got, want)
+	}
+	configured := Localise(WithDetail(renderer, DetailFull), "ko")
+	if _, error := RenderEntry(configured, renderentry); error == nil {
+		t.Errorf("RenderEntry(configured template) = nil; want error")
+	}
+	if _, error := RenderEntry(Localise(PlainText, "ko"), renderentry); error != nil {
+		t.Errorf("RenderEntry(PlainText) = %q; want no error", error)
+	}
+}
+
+func TestLoadTemplateRenderer(t *testing.T) {
+	renderer, error := LoadTemplateRenderer("", filepath.Join("..", "templates", "card.tmpl"), nil)
+	if error != nil {
+		t.Fatalf("LoadTemplateRenderer() = %q; want no error", error)
+	}
+	if renderer.Name() != "card" {
+		t.Errorf("LoadTemplateRenderer().Name() = %q; want %q", renderer.Name(), "card")
+	}
+	if renderer.Render(renderentry) == "" {
+		t.Errorf("LoadTemplateRenderer().Render() = empty string; want a message")
+	}
+	_, error = LoadTemplateRenderer("", filepath.Join(t.TempDir(), "missing.tmpl"), nil)
+	if error == nil || os.IsNotExist(error) {
+		t.Errorf("LoadTemplateRenderer(missing) = %v; want error", error)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		limit int
+		text  string
+		want  string
+	}{
+		{5, "hello", "hello"},
+		{5, "hello world", "hell…"},
+		{3, "안녕하세요", "안녕…"},
+		{0, "hello", ""},
+	}
+	for _, test := range tests {
+		got := Truncate(test.limit, test.text)
+		if got != test.want {
+			t.Errorf("Truncate(%d, %q) = %q; want %q", test.limit, test.text, got, test.want)
+		}
+	}
+}
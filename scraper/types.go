@@ -1,13 +1,93 @@
 package scraper
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // DictInfo is a struct to store dictionary information.
 type DictInfo struct {
-	Topik      string
-	Importance string
-	Title      string
-	Hanja      string
-	Endef      string
-	Pronun     string
-	Partspeech string
-	Meanings   string
+	Topik      string `json:"topik"`
+	Importance string `json:"importance"`
+	Title      string `json:"title"`
+	Hanja      string `json:"hanja"`
+	Endef      string `json:"english_definition"`
+	Pronun     string `json:"pronunciation"`
+	Partspeech string `json:"part_of_speech"`
+	Meanings   string `json:"meanings"`
+
+	TopikLevel      TopikLevel `json:"topik_level"`      // Topik as a number, for filtering and sorting.
+	ImportanceLevel int        `json:"importance_level"` // Importance as a number of stars from 0 to 3.
+}
+
+// TopikLevel is the TOPIK vocabulary level of a word.
+type TopikLevel int
+
+const (
+	TopikNone         TopikLevel = iota // Not a TOPIK word.
+	TopikElementary                     // TOPIK I
+	TopikIntermediate                   // TOPIK II
+)
+
+// Name of the level, as accepted by ParseTopikLevel.
+func (level TopikLevel) String() string {
+	switch level {
+	case TopikElementary:
+		return "elementary"
+	case TopikIntermediate:
+		return "intermediate"
+	}
+	return "none"
+}
+
+// Label of the level shown in messages, e.g. (TOPIK Elementary).
+func (level TopikLevel) Label() string {
+	return level.LabelIn(DefaultLocale)
+}
+
+// Label of the level in a locale, e.g. (TOPIK 초급).
+func (level TopikLevel) LabelIn(locale string) string {
+	switch level {
+	case TopikElementary:
+		return Translate(locale, LabelTopikElementary)
+	case TopikIntermediate:
+		return Translate(locale, LabelTopikIntermediate)
+	}
+	return ""
+}
+
+// Parse a TOPIK level from its number or name, e.g. 1 or elementary.
+func ParseTopikLevel(level string) (TopikLevel, error) {
+	normalised := strings.ToLower(strings.TrimSpace(level))
+	for _, known := range []TopikLevel{TopikNone, TopikElementary, TopikIntermediate} {
+		if normalised == known.String() || normalised == strconv.Itoa(int(known)) {
+			return known, nil
+		}
+	}
+	msg := fmt.Sprintf("unknown TOPIK level %q, want none, elementary, intermediate or 0 to 2", level)
+	return TopikNone, errors.New(msg)
+}
+
+// Filter selects DictInfos by TOPIK level and importance.
+type Filter struct {
+	Levels        []TopikLevel // Empty matches every level.
+	MinImportance int
+}
+
+// Whether the DictInfo passes the filter.
+func (filter Filter) Matches(dictinfo DictInfo) bool {
+	if dictinfo.ImportanceLevel < filter.MinImportance {
+		return false
+	}
+	if len(filter.Levels) == 0 {
+		return true
+	}
+	for _, level := range filter.Levels {
+		if dictinfo.TopikLevel == level {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns of a vocabulary export.
+const (
+	ColumnTerm          = "term"
+	ColumnHanja         = "hanja"
+	ColumnRomanisation  = "romanisation"
+	ColumnPronunciation = "pronunciation"
+	ColumnTopikLevel    = "topik_level"
+	ColumnImportance    = "importance"
+	ColumnGloss         = "gloss"   // The first gloss.
+	ColumnGlosses       = "glosses" // Every gloss, separated by "; ".
+	ColumnExample       = "example" // The first example, without emphasis.
+)
+
+// Value of each column for an Entry.
+var vocabularycolumns = map[string]func(entry Entry) string{
+	ColumnTerm:          func(entry Entry) string { return entry.Title },
+	ColumnHanja:         func(entry Entry) string { return entry.Hanja },
+	ColumnRomanisation:  func(entry Entry) string { return entry.Romanisation },
+	ColumnPronunciation: func(entry Entry) string { return entry.Pronunciation },
+	ColumnTopikLevel: func(entry Entry) string {
+		if entry.TopikLevel == TopikNone {
+			return ""
+		}
+		return entry.TopikLevel.String()
+	},
+	ColumnImportance: func(entry Entry) string { return strconv.Itoa(entry.Importance) },
+	ColumnGloss: func(entry Entry) string {
+		if len(entry.Glosses) == 0 {
+			return ""
+		}
+		return entry.Glosses[0]
+	},
+	ColumnGlosses: func(entry Entry) string { return strings.Join(entry.Glosses, "; ") },
+	ColumnExample: func(entry Entry) string {
+		for _, sense := range entry.Senses {
+			if len(sense.Examples) > 0 {
+				return sense.Examples[0].Text
+			}
+		}
+		return ""
+	},
+}
+
+// Columns exported when none are chosen, as expected by Quizlet: the term
+// and its definition.
+var DefaultVocabularyColumns = []string{ColumnTerm, ColumnGlosses}
+
+// VocabularyFormat is a flat export of Entries, one row per Entry, that
+// spreadsheets and Quizlet can import.
+type VocabularyFormat struct {
+	Columns       []string // DefaultVocabularyColumns if empty.
+	Separator     rune
+	Header        bool // Whether the first row names the columns.
+	ByteOrderMark bool // Whether to start with a UTF-8 byte order mark, so that Excel reads Hangul as UTF-8.
+}
+
+// Comma-separated values with a header row and a byte order mark.
+var CSV = VocabularyFormat{Separator: ',', Header: true, ByteOrderMark: true}
+
+// Tab-separated values without a header row, as pasted into Quizlet.
+var TSV = VocabularyFormat{Separator: '\t'}
+
+// Parse comma-separated column names, e.g. term,hanja,gloss. The American
+// spelling romanization is accepted too.
+func ParseVocabularyColumns(columns string) ([]string, error) {
+	parsed := []string{}
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "romanization" {
+			column = ColumnRomanisation
+		}
+		if _, found := vocabularycolumns[column]; !found {
+			msg := fmt.Sprintf("unknown column %q, want some of %s", column, strings.Join(VocabularyColumnNames(), ", "))
+			return nil, errors.New(msg)
+		}
+		parsed = append(parsed, column)
+	}
+	return parsed, nil
+}
+
+// Names of all columns, in their usual order.
+func VocabularyColumnNames() []string {
+	return []string{ColumnTerm, ColumnHanja, ColumnRomanisation, ColumnPronunciation, ColumnTopikLevel, ColumnImportance, ColumnGloss, ColumnGlosses, ColumnExample}
+}
+
+// Write Entries in the format. Fields with separators, quotes or line
+// breaks are quoted, and fields that spreadsheets would read as formulas
+// are prefixed with ', see spreadsheetcell.
+func (format VocabularyFormat) Write(writer io.Writer, entries []Entry) error {
+	columns := format.Columns
+	if len(columns) == 0 {
+		columns = DefaultVocabularyColumns
+	}
+	for _, column := range columns {
+		if _, found := vocabularycolumns[column]; !found {
+			msg := fmt.Sprintf("unknown column %q", column)
+			return errors.New(msg)
+		}
+	}
+
+	if format.ByteOrderMark {
+		if _, errwrite := io.WriteString(writer, "\ufeff"); errwrite != nil {
+			return errwrite
+		}
+	}
+	csvwriter := csv.NewWriter(writer)
+	if format.Separator != 0 {
+		csvwriter.Comma = format.Separator
+	}
+	if format.Header {
+		csvwriter.Write(columns)
+	}
+	for _, entry := range entries {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = spreadsheetcell(vocabularycolumns[column](entry))
+		}
+		csvwriter.Write(row)
+	}
+	csvwriter.Flush()
+	return csvwriter.Error()
+}
+
+// Prefix a field starting with =, +, -, @, a tab or a carriage return with
+// ', so that Excel and Sheets show it as text instead of running it as a
+// formula. Entries of exports can come from clients.
+func spreadsheetcell(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVocabularyColumns(t *testing.T) {
+	got, errparse := ParseVocabularyColumns("Term, romanization,gloss")
+	if errparse != nil || strings.Join(got, ",") != "term,romanisation,gloss" {
+		t.Errorf("ParseVocabularyColumns() = %q, %v; want term,romanisation,gloss", got, errparse)
+	}
+	_, errparse = ParseVocabularyColumns("term,meaning")
+	if errparse == nil {
+		t.Errorf("ParseVocabularyColumns(%q) = nil; want error", "term,meaning")
+	}
+}
+
+func TestVocabularyCSV(t *testing.T) {
+	var output strings.Builder
+	format := CSV
+	format.Columns = VocabularyColumnNames()
+	errwrite := format.Write(&output, []Entry{renderentry, {Title: "나무", Glosses: []string{"tree", "wood, timber"}}})
+	want := "\ufeffterm,hanja,romanisation,pronunciation,topik_level,importance,gloss,glosses,example\n" +
+		"안녕,安寧,an-nyeong,안녕,elementary,3,hello,hello; peace,가족의 안녕을 빌다.\n" +
+		"나무,,,,,0,tree,\"tree; wood, timber\",\n"
+	if errwrite != nil || output.String() != want {
+		t.Errorf("CSV.Write() = %q, %v; want %q", output.String(), errwrite, want)
+	}
+}
+
+func TestVocabularyTSV(t *testing.T) {
+	var output strings.Builder
+	entry := Entry{Title: "가다", Glosses: []string{"go\nleave", "say \"bye\""}}
+	errwrite := TSV.Write(&output, []Entry{entry})
+	want := "가다\t\"go\nleave; say \"\"bye\"\"\"\n"
+	if errwrite != nil || output.String() != want {
+		t.Errorf("TSV.Write() = %q, %v; want %q", output.String(), errwrite, want)
+	}
+	errwrite = VocabularyFormat{Columns: []string{"meaning"}}.Write(&output, []Entry{entry})
+	if errwrite == nil {
+		t.Errorf("Write(unknown column) = nil; want error")
+	}
+}
+
+func TestVocabularyFormulas(t *testing.T) {
+	var output strings.Builder
+	entry := Entry{Title: "=HYPERLINK(\"http://example.com\")", Glosses: []string{"+1", "-ish"}, Senses: []Sense{{Examples: []Markup{{Text: "@SUM(A1)"}}}}}
+	format := VocabularyFormat{Columns: []string{ColumnTerm, ColumnGloss, ColumnExample}}
+	errwrite := format.Write(&output, []Entry{entry, {Title: "나무", Glosses: []string{"a-b"}}})
+	want := "\"'=HYPERLINK(\"\"http://example.com\"\")\",'+1,'@SUM(A1)\n" +
+		"나무,a-b,\n"
+	if errwrite != nil || output.String() != want {
+		t.Errorf("Write() = %q, %v; want %q", output.String(), errwrite, want)
+	}
+}
@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Version of the JSON wire format of lookup responses. Renaming or removing
+// a field, or changing its type, is a breaking change and needs a new
+// version; adding a field does not.
+const SchemaVersion = 1
+
+// LookupResponse is the JSON wire format of a DictInfo, as served by /get.
+type LookupResponse struct {
+	SchemaVersion int      `json:"schema_version"`
+	Message       DictInfo `json:"message"`
+}
+
+// EntryResponse is the JSON wire format of an Entry, as served by /get/entry.
+type EntryResponse struct {
+	SchemaVersion int   `json:"schema_version"`
+	Message       Entry `json:"message"`
+}
+
+// Wrap a DictInfo in the current wire format.
+func NewLookupResponse(dictinfo DictInfo) LookupResponse {
+	return LookupResponse{SchemaVersion, dictinfo}
+}
+
+// Wrap an Entry in the current wire format.
+func NewEntryResponse(entry Entry) EntryResponse {
+	return EntryResponse{SchemaVersion, entry}
+}
+
+// Published JSON Schemas of the wire format, by file name in schemas/.
+var WireSchemas = map[string]interface{}{
+	"lookup.schema.json": LookupResponse{},
+	"entry.schema.json":  EntryResponse{},
+}
+
+// Generate the JSON Schema of a wire format type, e.g. LookupResponse{}.
+// Fields without omitempty are required, and schema_version is fixed to
+// SchemaVersion.
+func JSONSchema(value interface{}) ([]byte, error) {
+	valuetype := reflect.TypeOf(value)
+	schema := typeschema(valuetype)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = valuetype.Name()
+	if properties, found := schema["properties"].(map[string]interface{}); found {
+		if version, found := properties["schema_version"].(map[string]interface{}); found {
+			version["const"] = SchemaVersion
+		}
+	}
+	data, errencode := json.MarshalIndent(schema, "", "  ")
+	if errencode != nil {
+		return nil, errencode
+	}
+	return append(data, '\n'), nil
+}
+
+// Get the JSON Schema of the type of a value, without $schema and title,
+// e.g. to describe it in an OpenAPI document.
+func TypeSchema(value interface{}) map[string]interface{} {
+	return typeschema(reflect.TypeOf(value))
+}
+
+// Get the JSON Schema of a Go type.
+func typeschema(valuetype reflect.Type) map[string]interface{} {
+	if valuetype == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch valuetype.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeschema(valuetype.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeschema(valuetype.Elem())}
+	case reflect.Pointer:
+		return typeschema(valuetype.Elem())
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for i := 0; i < valuetype.NumField(); i++ {
+			field := valuetype.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = typeschema(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	}
+	return map[string]interface{}{}
+}
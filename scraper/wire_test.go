@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The published schemas must match the types. Set NAVERDICT_UPDATE_SCHEMA
+// to regenerate them, after bumping SchemaVersion for breaking changes.
+func TestWireSchemasUpToDate(t *testing.T) {
+	for name, value := range WireSchemas {
+		path := filepath.Join("..", "schemas", name)
+		got, errschema := JSONSchema(value)
+		if errschema != nil {
+			t.Fatalf("JSONSchema(%T) = %q; want no error", value, errschema)
+		}
+		if os.Getenv("NAVERDICT_UPDATE_SCHEMA") != "" {
+			os.WriteFile(path, got, 0o644)
+			continue
+		}
+		want, errread := os.ReadFile(path)
+		if errread != nil {
+			t.Fatalf("cannot read %s: %v", path, errread)
+		}
+		if string(got) != string(want) {
+			t.Errorf("JSONSchema(%T) differs from %s; run go run ./cmd/naverdict schema schemas", value, path)
+		}
+	}
+}
+
+// Responses must keep the wire format of their schema version, compared
+// against the golden files in testdata/wire.
+func TestWireCompatibility(t *testing.T) {
+	entry, _ := ScrapeEntry(examplesearchinfo)
+	dictinfo, _ := Scrape(examplesearchinfo)
+	tests := map[string]interface{}{
+		"lookup_v1.json": NewLookupResponse(dictinfo),
+		"entry_v1.json":  NewEntryResponse(entry),
+	}
+	for name, response := range tests {
+		path := filepath.Join("testdata", "wire", name)
+		got, errencode := json.MarshalIndent(response, "", "  ")
+		if errencode != nil {
+			t.Fatalf("json.MarshalIndent(%T) = %q; want no error", response, errencode)
+		}
+		if os.Getenv("NAVERDICT_UPDATE_SCHEMA") != "" {
+			os.WriteFile(path, append(got, '\n'), 0o644)
+			continue
+		}
+		want, errread := os.ReadFile(path)
+		if errread != nil {
+			t.Fatalf("cannot read %s: %v", path, errread)
+		}
+		if string(got)+"\n" != string(want) {
+			t.Errorf("%T = %s; want %s", response, got, want)
+		}
+	}
+}
+
+func TestJSONSchemaRequired(t *testing.T) {
+	data, _ := JSONSchema(Markup{})
+	var schema map[string]interface{}
+	json.Unmarshal(data, &schema)
+	required, _ := schema["required"].([]interface{})
+	if len(required) != 1 || required[0] != "text" {
+		t.Errorf("JSONSchema(Markup{}).required = %v; want [text]", required)
+	}
+}
+
+func TestTypeSchema(t *testing.T) {
+	schema := TypeSchema(SchemaReport{})
+	properties, _ := schema["properties"].(map[string]interface{})
+	lastseen, _ := properties["last_seen"].(map[string]interface{})
+	items, _ := lastseen["additionalProperties"].(map[string]interface{})
+	if items["type"] != "string" || items["format"] != "date-time" {
+		t.Errorf("TypeSchema(SchemaReport{}).last_seen = %v; want a map of date-time strings", lastseen)
+	}
+}